@@ -0,0 +1,274 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tandy9527/js-util/tools/str_tools"
+)
+
+// ---------------- Bitmap 命令 ----------------
+
+// SetBit 设置 offset 位，返回原值
+func (c *RedisClient) SetBit(key string, offset int64, value int, timeout ...time.Duration) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.SetBit(ctx, key, offset, value).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// GetBit 读取 offset 位，key 不存在返回 0
+func (c *RedisClient) GetBit(key string, offset int64, timeout ...time.Duration) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.GetBit(ctx, key, offset).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// BitCount 统计值为 1 的位数
+func (c *RedisClient) BitCount(key string, timeout ...time.Duration) (int64, error) {
+	return c.bitCount(key, nil, timeout...)
+}
+
+// BitCountRange 统计字节范围 [start, end] 内值为 1 的位数
+func (c *RedisClient) BitCountRange(key string, start, end int64, timeout ...time.Duration) (int64, error) {
+	return c.bitCount(key, &redis.BitCount{Start: start, End: end}, timeout...)
+}
+
+func (c *RedisClient) bitCount(key string, bc *redis.BitCount, timeout ...time.Duration) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.BitCount(ctx, key, bc).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// BitPos 第一个值为 bit 的位置，不存在返回 -1
+// pos 可选：起始字节、结束字节
+func (c *RedisClient) BitPos(key string, bit int64, pos ...int64) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.BitPos(ctx, key, bit, pos...).Result()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// BitOp 位运算类型
+type BitOp string
+
+const (
+	BitAnd BitOp = "AND"
+	BitOr  BitOp = "OR"
+	BitXor BitOp = "XOR"
+	BitNot BitOp = "NOT" // 只接受一个源 key
+)
+
+// BitOp 对 keys 做位运算，结果写入 dest，返回 dest 的字节长度
+// cluster 模式下所有 key 需在同一 slot
+func (c *RedisClient) BitOp(op BitOp, dest string, keys ...string) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		switch op {
+		case BitAnd:
+			return c.rdb.BitOpAnd(ctx, dest, keys...).Result()
+		case BitOr:
+			return c.rdb.BitOpOr(ctx, dest, keys...).Result()
+		case BitXor:
+			return c.rdb.BitOpXor(ctx, dest, keys...).Result()
+		case BitNot:
+			if len(keys) != 1 {
+				return nil, fmt.Errorf("cache: BITOP NOT expects 1 key, got %d", len(keys))
+			}
+			return c.rdb.BitOpNot(ctx, dest, keys[0]).Result()
+		}
+		return nil, fmt.Errorf("cache: unknown bit op %q", op)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// ---------------- 活跃 / 签到 ----------------
+
+// ActivityTracker 基于 bitmap 的每日活跃与签到统计
+// 每日全体活跃：prefix:{name}:d:yyyymmdd，offset=uid，用于日活和留存（BITOP）
+// 个人月签到：prefix:name:u:<uid>:yyyymm，offset=日-1，用于连续签到和月签到天数
+// uid 作为位偏移，需为较小的非负整数（< 2^32），稀疏的大 uid 会浪费内存
+type ActivityTracker struct {
+	cli     *RedisClient
+	dayBase string
+	usrBase string
+	ttl     time.Duration
+}
+
+// NewActivityTracker 创建活跃统计
+// ttl: 每日 key 和个人月 key 的保留时长，<=0 不过期
+//
+//	checkin := cache.GetDB(cache.DB0).NewActivityTracker("game", "checkin", 400*24*time.Hour)
+//	first, _ := checkin.Mark(uid)
+//	streak, _ := checkin.Streak(uid, time.Now())
+func (c *RedisClient) NewActivityTracker(prefix, name string, ttl time.Duration) *ActivityTracker {
+	return &ActivityTracker{
+		cli:     c,
+		dayBase: prefix + keySep + "{" + name + "}" + keySep + "d" + keySep,
+		usrBase: prefix + keySep + name + keySep + "u" + keySep,
+		ttl:     ttl,
+	}
+}
+
+// DayKey 每日全体活跃 key
+func (a *ActivityTracker) DayKey(t time.Time) string {
+	return a.dayBase + t.Format("20060102")
+}
+
+// UserKey 个人月签到 key
+func (a *ActivityTracker) UserKey(uid int64, t time.Time) string {
+	return a.usrBase + formatSegment(uid) + keySep + t.Format("200601")
+}
+
+// Mark 记录 uid 今天活跃，当天首次记录返回 true
+func (a *ActivityTracker) Mark(uid int64) (bool, error) {
+	return a.MarkAt(uid, time.Now())
+}
+
+// MarkAt 记录 uid 在 t 所在日期活跃，当天首次记录返回 true
+func (a *ActivityTracker) MarkAt(uid int64, t time.Time) (bool, error) {
+	dayKey, usrKey := a.DayKey(t), a.UserKey(uid, t)
+	cmds, err := a.cli.Pipelined(func(ctx context.Context, pipe redis.Pipeliner) error {
+		pipe.SetBit(ctx, usrKey, int64(t.Day()-1), 1)
+		pipe.SetBit(ctx, dayKey, uid, 1)
+		if a.ttl > 0 {
+			pipe.Expire(ctx, usrKey, a.ttl)
+			pipe.Expire(ctx, dayKey, a.ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return cmds[0].(*redis.IntCmd).Val() == 0, nil
+}
+
+// Active uid 在 t 所在日期是否活跃
+func (a *ActivityTracker) Active(uid int64, t time.Time) (bool, error) {
+	bit, err := a.cli.GetBit(a.UserKey(uid, t), int64(t.Day()-1))
+	return bit == 1, err
+}
+
+// DailyCount t 所在日期的活跃人数
+func (a *ActivityTracker) DailyCount(t time.Time) (int64, error) {
+	return a.cli.BitCount(a.DayKey(t))
+}
+
+// MonthDays uid 在 t 所在月份的活跃天数
+func (a *ActivityTracker) MonthDays(uid int64, t time.Time) (int64, error) {
+	return a.cli.BitCount(a.UserKey(uid, t))
+}
+
+// MonthHistory uid 在 t 所在月份每天是否活跃，下标 0 为 1 号，可用于签到日历
+func (a *ActivityTracker) MonthHistory(uid int64, t time.Time) ([]bool, error) {
+	bits, err := a.monthBits(uid, t)
+	if err != nil {
+		return nil, err
+	}
+	days := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	res := make([]bool, days)
+	for i := range res {
+		res[i] = bitAt(bits, i)
+	}
+	return res, nil
+}
+
+// Streak 截至 t 的连续活跃天数，t 当天未活跃时从前一天开始计算（当天还可以签到）
+func (a *ActivityTracker) Streak(uid int64, t time.Time) (int, error) {
+	y, m, d := t.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	bits, err := a.monthBits(uid, day)
+	if err != nil {
+		return 0, err
+	}
+	if !bitAt(bits, day.Day()-1) {
+		day = day.AddDate(0, 0, -1)
+	}
+	streak := 0
+	month := day.Month()
+	for {
+		if day.Month() != month {
+			// 跨月读取上个月
+			month = day.Month()
+			if bits, err = a.monthBits(uid, day); err != nil {
+				return 0, err
+			}
+		}
+		if !bitAt(bits, day.Day()-1) {
+			return streak, nil
+		}
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+}
+
+// CountAll 在 days 每一天都活跃的人数，如留存：CountAll(注册日, 第 N 日)
+func (a *ActivityTracker) CountAll(days ...time.Time) (int64, error) {
+	return a.countOp(BitAnd, days)
+}
+
+// CountAny 在 days 任意一天活跃的人数，如周活、月活
+func (a *ActivityTracker) CountAny(days ...time.Time) (int64, error) {
+	return a.countOp(BitOr, days)
+}
+
+// countOp BITOP 到临时 key 后计数，临时 key 与每日 key 在同一 slot
+func (a *ActivityTracker) countOp(op BitOp, days []time.Time) (int64, error) {
+	if len(days) == 0 {
+		return 0, nil
+	}
+	keys := make([]string, len(days))
+	for i, d := range days {
+		keys[i] = a.DayKey(d)
+	}
+	tmp := a.dayBase + "tmp" + keySep + str_tools.RandLetterStr(12)
+	cmds, err := a.cli.Pipelined(func(ctx context.Context, pipe redis.Pipeliner) error {
+		if op == BitAnd {
+			pipe.BitOpAnd(ctx, tmp, keys...)
+		} else {
+			pipe.BitOpOr(ctx, tmp, keys...)
+		}
+		pipe.BitCount(ctx, tmp, nil)
+		pipe.Del(ctx, tmp)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return cmds[1].(*redis.IntCmd).Val(), nil
+}
+
+// monthBits 读取个人月签到 bitmap，不存在返回空
+func (a *ActivityTracker) monthBits(uid int64, t time.Time) ([]byte, error) {
+	s, err := a.cli.Get(a.UserKey(uid, t))
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	return []byte(s), err
+}
+
+// bitAt Redis bitmap 按大端位序存储，offset 0 为第一个字节的最高位
+func bitAt(bits []byte, offset int) bool {
+	if offset < 0 || offset/8 >= len(bits) {
+		return false
+	}
+	return bits[offset/8]&(0x80>>(offset%8)) != 0
+}
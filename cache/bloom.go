@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis bitmap 最大 2^32 位（512MB）
+const maxBloomBits = 1 << 32
+
+// KEYS[1]=bitmap  ARGV[1]=k ARGV[2...]=每个元素的 k 个位偏移
+// 返回每个元素是否有位由 0 变 1（之前不存在）
+var bloomAddScript = RegisterScript("cache.bloom_add", `
+local k = tonumber(ARGV[1])
+local res = {}
+for i = 2, #ARGV, k do
+	local added = 0
+	for j = i, i + k - 1 do
+		if redis.call('SETBIT', KEYS[1], ARGV[j], 1) == 0 then
+			added = 1
+		end
+	end
+	table.insert(res, added)
+end
+return res
+`)
+
+// BloomFilter 基于 Redis bitmap 的布隆过滤器，用于“是否见过”的低成本判断
+// 判断不存在一定准确，判断存在有 fpRate 的误判率；不支持删除单个元素
+type BloomFilter struct {
+	cli  *RedisClient
+	key  string
+	bits uint64 // 位数 m
+	k    int    // 哈希函数个数
+}
+
+// NewBloomFilter 创建布隆过滤器
+// capacity: 预计元素数量，超出后误判率上升
+// fpRate: 期望误判率，如 0.001
+//
+//	seen := cache.GetDB(cache.DB0).NewBloomFilter("bloom:device", 10_000_000, 0.001)
+//	isNew, err := seen.Add(deviceID)
+func (c *RedisClient) NewBloomFilter(key string, capacity uint64, fpRate float64) *BloomFilter {
+	if capacity == 0 {
+		capacity = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	// m = -n*ln(p)/(ln2)^2，k = m/n*ln2
+	m := math.Ceil(-float64(capacity) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	bits := uint64(min(m, maxBloomBits))
+	k := int(math.Round(float64(bits) / float64(capacity) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &BloomFilter{cli: c, key: key, bits: bits, k: k}
+}
+
+// Key bitmap 所在 key
+func (b *BloomFilter) Key() string { return b.key }
+
+// Bits 位数
+func (b *BloomFilter) Bits() uint64 { return b.bits }
+
+// Hashes 哈希函数个数
+func (b *BloomFilter) Hashes() int { return b.k }
+
+// Add 添加元素，元素之前不存在（至少一位由 0 变 1）时返回 true
+func (b *BloomFilter) Add(item string) (bool, error) {
+	res, err := b.AddMulti(item)
+	if err != nil {
+		return false, err
+	}
+	return res[0], nil
+}
+
+// AddMulti 批量添加，返回每个元素之前是否不存在
+// 在 Lua 中原子执行，并发添加同一元素只有一个返回 true
+func (b *BloomFilter) AddMulti(items ...string) ([]bool, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	args := make([]any, 0, 1+len(items)*b.k)
+	args = append(args, b.k)
+	for _, item := range items {
+		for _, off := range b.offsets(item) {
+			args = append(args, off)
+		}
+	}
+	added, err := LuaInt64s(bloomAddScript.Run(b.cli, []string{b.key}, args...))
+	if err != nil {
+		return nil, err
+	}
+	res := make([]bool, len(items))
+	for i := range res {
+		res[i] = i < len(added) && added[i] == 1
+	}
+	return res, nil
+}
+
+// Exists 元素是否可能存在
+func (b *BloomFilter) Exists(item string) (bool, error) {
+	res, err := b.ExistsMulti(item)
+	if err != nil {
+		return false, err
+	}
+	return res[0], nil
+}
+
+// ExistsMulti 批量判断元素是否可能存在
+func (b *BloomFilter) ExistsMulti(items ...string) ([]bool, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	cmds, err := b.cli.Pipelined(func(ctx context.Context, pipe redis.Pipeliner) error {
+		for _, item := range items {
+			for _, off := range b.offsets(item) {
+				pipe.GetBit(ctx, b.key, int64(off))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 任一位为 0 即不存在
+	res := b.missing(items, cmds)
+	for i := range res {
+		res[i] = !res[i]
+	}
+	return res, nil
+}
+
+// missing 每个元素的 k 个 GETBIT 结果中是否有为 0 的位
+func (b *BloomFilter) missing(items []string, cmds []redis.Cmder) []bool {
+	res := make([]bool, len(items))
+	for i := range items {
+		for _, cmd := range cmds[i*b.k : (i+1)*b.k] {
+			if cmd.(*redis.IntCmd).Val() == 0 {
+				res[i] = true
+				break
+			}
+		}
+	}
+	return res
+}
+
+// Reset 清空过滤器
+func (b *BloomFilter) Reset() error {
+	return b.cli.Del(b.key)
+}
+
+// offsets 双重哈希：h1 + i*h2，h1/h2 取自 128 位 FNV-1a
+func (b *BloomFilter) offsets(item string) []uint64 {
+	h := fnv.New128a()
+	h.Write([]byte(item))
+	sum := h.Sum(nil)
+	h1 := binary.BigEndian.Uint64(sum[:8])
+	h2 := binary.BigEndian.Uint64(sum[8:]) | 1
+	offs := make([]uint64, b.k)
+	for i := range offs {
+		offs[i] = (h1 + uint64(i)*h2) % b.bits
+	}
+	return offs
+}
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Codec 值的序列化方式
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	JSONCodec Codec = jsonCodec{}
+	GobCodec  Codec = gobCodec{}
+	// RawCodec 不做转换，只支持 string / []byte
+	RawCodec Codec = rawCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	switch val := v.(type) {
+	case string:
+		return []byte(val), nil
+	case []byte:
+		return val, nil
+	default:
+		return nil, fmt.Errorf("cache: raw codec cannot marshal %T", v)
+	}
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	switch ptr := v.(type) {
+	case *string:
+		*ptr = string(data)
+	case *[]byte:
+		*ptr = append((*ptr)[:0], data...)
+	default:
+		return fmt.Errorf("cache: raw codec cannot unmarshal into %T", v)
+	}
+	return nil
+}
+
+// WithCodec 返回使用指定编解码的客户端视图，共享底层连接池
+func (c *RedisClient) WithCodec(codec Codec) *RedisClient {
+	cc := *c
+	cc.codec = codec
+	return &cc
+}
+
+// Codec 返回客户端的编解码方式，未设置时为 JSONCodec
+func (c *RedisClient) Codec() Codec {
+	if c.codec != nil {
+		return c.codec
+	}
+	return JSONCodec
+}
+
+// GetAs 读取 key 并解码为 T，key 不存在返回 ErrNotFound
+//
+//	user, err := cache.GetAs[User](cache.GetDB(cache.DB0), "user:1")
+func GetAs[T any](c *RedisClient, key string, timeout ...time.Duration) (T, error) {
+	var val T
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.Get(ctx, key).Bytes()
+	}, timeout...)
+	if err != nil {
+		return val, err
+	}
+	if err := c.Codec().Unmarshal(res.([]byte), &val); err != nil {
+		return val, err
+	}
+	return val, nil
+}
+
+// SetAs 编码 val 后写入 key
+func SetAs[T any](c *RedisClient, key string, val T, expiration time.Duration, timeout ...time.Duration) error {
+	data, err := c.Codec().Marshal(val)
+	if err != nil {
+		return err
+	}
+	return c.Set(key, data, expiration, timeout...)
+}
+
+// HGetAllAs 读取整个 hash 并按 `redis:"field"` 标签映射到结构体 T
+// 只支持基础类型字段，hash 不存在返回 ErrNotFound
+func HGetAllAs[T any](c *RedisClient, hashKey string, timeout ...time.Duration) (T, error) {
+	var val T
+	_, err := c.do(func(ctx context.Context) (any, error) {
+		cmd := c.rdb.HGetAll(ctx, hashKey)
+		if err := cmd.Err(); err != nil {
+			return nil, err
+		}
+		if len(cmd.Val()) == 0 {
+			return nil, ErrNotFound
+		}
+		return nil, cmd.Scan(&val)
+	}, timeout...)
+	return val, err
+}
+
+// HSetAs 按 `redis:"field"` 标签将结构体 val 写入 hash，未打标签的字段忽略
+func HSetAs[T any](c *RedisClient, hashKey string, val T, timeout ...time.Duration) error {
+	_, err := c.do(func(ctx context.Context) (any, error) {
+		return nil, c.rdb.HSet(ctx, hashKey, val).Err()
+	}, timeout...)
+	return err
+}
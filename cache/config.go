@@ -3,12 +3,21 @@ package cache
 import "github.com/tandy9527/js-util/tools"
 
 type RedisConf struct {
+	Mode         string `yaml:"mode"` // standalone(默认) / sentinel / cluster
 	Addr         string `yaml:"addr"`
 	Password     string `yaml:"password"`
-	DB           int    `yaml:"db"`
+	DB           int    `yaml:"db"` // cluster 模式只支持 0
 	PoolSize     int    `yaml:"pool_size"`
 	MinIdleConns int    `yaml:"min_idle_conns"`
 	PoolTimeout  int    `yaml:"pool_timeout"`
+
+	// sentinel 模式
+	MasterName       string   `yaml:"master_name"`
+	SentinelAddrs    []string `yaml:"sentinel_addrs"`
+	SentinelPassword string   `yaml:"sentinel_password"`
+
+	// cluster 模式，种子节点
+	ClusterAddrs []string `yaml:"cluster_addrs"`
 }
 type RedisMap struct {
 	Redis map[string]RedisConf `yaml:"redis"`
@@ -18,3 +18,10 @@ const (
 	DB14 = "db14"
 	DB15 = "db15"
 )
+
+// Redis 部署模式
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrNotFound key / 字段 / 成员不存在，或阻塞命令超时无数据
+	// errors.Is(ErrNotFound, redis.Nil) 为 true，兼容原先判断 redis.Nil 的调用方
+	ErrNotFound error = notFoundError{}
+	// ErrTimeout 命令超时，errors.Is(err, context.DeadlineExceeded) 仍然成立
+	ErrTimeout = errors.New("cache: timeout")
+)
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string { return "cache: not found" }
+
+func (notFoundError) Is(target error) bool { return target == redis.Nil }
+
+// IsNotFound 是否为不存在错误
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsTimeout 是否为超时错误
+func IsTimeout(err error) bool {
+	return errors.Is(err, ErrTimeout)
+}
+
+// wrapErr 统一转换 go-redis 返回的错误
+func wrapErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	if err == redis.Nil {
+		return ErrNotFound
+	}
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrTimeout) {
+		return err
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return err
+}
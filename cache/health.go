@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tandy9527/js-util/logger"
+)
+
+const defaultHealthTimeout = time.Second
+
+// PoolStats 连接池统计，字段含义同 go-redis PoolStats，计数为启动以来的累计值
+type PoolStats struct {
+	Hits       uint32 `json:"hits"`        // 从池中取到空闲连接的次数
+	Misses     uint32 `json:"misses"`      // 池中无空闲连接、新建连接的次数
+	Timeouts   uint32 `json:"timeouts"`    // 等待连接超时（PoolTimeout）的次数，持续增长说明池过小
+	TotalConns uint32 `json:"total_conns"` // 当前连接数
+	IdleConns  uint32 `json:"idle_conns"`  // 当前空闲连接数
+	StaleConns uint32 `json:"stale_conns"` // 被关闭的过期连接数
+}
+
+// ClientHealth 单个客户端的健康状态
+type ClientHealth struct {
+	Name    string        `json:"name"`
+	Healthy bool          `json:"healthy"`
+	Latency time.Duration `json:"latency_ns"` // Ping 耗时
+	Error   string        `json:"error,omitempty"`
+	Pool    PoolStats     `json:"pool"`
+}
+
+// Ping 探测连接，返回往返耗时
+func (c *RedisClient) Ping(timeout ...time.Duration) (time.Duration, error) {
+	start := time.Now()
+	_, err := c.do(func(ctx context.Context) (any, error) {
+		return nil, c.rdb.Ping(ctx).Err()
+	}, timeout...)
+	return time.Since(start), err
+}
+
+// PoolStats 连接池统计，cluster / 哨兵模式下为所有节点之和
+func (c *RedisClient) PoolStats() PoolStats {
+	s := c.rdb.PoolStats()
+	return PoolStats{
+		Hits:       s.Hits,
+		Misses:     s.Misses,
+		Timeouts:   s.Timeouts,
+		TotalConns: s.TotalConns,
+		IdleConns:  s.IdleConns,
+		StaleConns: s.StaleConns,
+	}
+}
+
+// Health 并发 Ping 所有客户端，按名称排序返回
+// timeout: 单个客户端的 Ping 超时，<=0 时默认 1s
+func (m *RedisManager) Health(timeout time.Duration) []ClientHealth {
+	if timeout <= 0 {
+		timeout = defaultHealthTimeout
+	}
+	m.mu.RLock()
+	clients := make(map[string]*RedisClient, len(m.clients))
+	for name, cli := range m.clients {
+		clients[name] = cli
+	}
+	m.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	res := make([]ClientHealth, 0, len(clients))
+	var mu sync.Mutex
+	for name, cli := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			latency, err := cli.WithContext(context.Background()).Ping(timeout)
+			h := ClientHealth{Name: name, Healthy: err == nil, Latency: latency, Pool: cli.PoolStats()}
+			if err != nil {
+				h.Error = err.Error()
+			}
+			mu.Lock()
+			res = append(res, h)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+// Healthy 所有客户端都可用时返回 true
+func (m *RedisManager) Healthy(timeout time.Duration) bool {
+	for _, h := range m.Health(timeout) {
+		if !h.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// HealthHandler 就绪检查接口，全部可用返回 200，否则返回 503，响应体为各客户端状态的 JSON
+func (m *RedisManager) HealthHandler(timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		list := m.Health(timeout)
+		status := http.StatusOK
+		for _, h := range list {
+			if !h.Healthy {
+				status = http.StatusServiceUnavailable
+				break
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(list)
+	})
+}
+
+// LogStats 每隔 interval 记录一次所有客户端的健康状态和连接池统计，返回停止函数
+// 不可用或连接池等待超时次数增加时记录 Warn，其余记录 Info
+func (m *RedisManager) LogStats(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		lastTimeouts := make(map[string]uint32)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				for _, h := range m.Health(0) {
+					p := h.Pool
+					grown := p.Timeouts > lastTimeouts[h.Name]
+					lastTimeouts[h.Name] = p.Timeouts
+					if !h.Healthy || grown {
+						logger.Warnf("Redis[%s] healthy=%v latency=%v err=%s pool: total=%d idle=%d hits=%d misses=%d timeouts=%d",
+							h.Name, h.Healthy, h.Latency, h.Error, p.TotalConns, p.IdleConns, p.Hits, p.Misses, p.Timeouts)
+						continue
+					}
+					logger.Infof("Redis[%s] healthy latency=%v pool: total=%d idle=%d hits=%d misses=%d timeouts=%d",
+						h.Name, h.Latency, p.TotalConns, p.IdleConns, p.Hits, p.Misses, p.Timeouts)
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// Health 默认管理器所有客户端的健康状态，未初始化返回 nil
+func Health(timeout time.Duration) []ClientHealth {
+	m := defaultManager.Load()
+	if m == nil {
+		return nil
+	}
+	return m.Health(timeout)
+}
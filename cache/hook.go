@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tandy9527/js-util/logger"
+)
+
+// CommandInfo 一次命令执行的信息
+type CommandInfo struct {
+	Client   string        // 客户端名，即配置中的 key
+	Name     string        // 命令名（小写），管道为 "pipeline"
+	Key      string        // 第一个 key，无 key 的命令为空；管道为第一条命令的 key
+	Cmds     int           // 命令条数，管道时大于 1
+	Duration time.Duration // 耗时
+	Err      error         // 错误，已按 wrapErr 转换，key 不存在不算错误
+	Blocking bool          // 阻塞命令（BLPOP、XREADGROUP BLOCK 等），耗时包含等待时间
+}
+
+// Hook 命令执行后回调，所有客户端的每条命令（含管道、Lua、订阅）都会触发
+// 在命令所在 goroutine 同步调用，实现需快速返回且并发安全
+type Hook interface {
+	AfterCommand(ctx context.Context, info CommandInfo)
+}
+
+// HookFunc 函数形式的 Hook
+type HookFunc func(ctx context.Context, info CommandInfo)
+
+func (f HookFunc) AfterCommand(ctx context.Context, info CommandInfo) { f(ctx, info) }
+
+var (
+	hooksMu sync.Mutex
+	hooks   atomic.Pointer[[]Hook]
+)
+
+// AddHook 注册全局 Hook，对已创建和之后创建的客户端都生效
+//
+//	cache.AddHook(cache.NewSlowLogHook(50 * time.Millisecond))
+//	cache.AddHook(cache.DefaultMetrics)
+func AddHook(h Hook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	var list []Hook
+	if old := hooks.Load(); old != nil {
+		list = append(list, *old...)
+	}
+	list = append(list, h)
+	hooks.Store(&list)
+}
+
+// NewSlowLogHook 耗时超过 threshold 的命令记录 Warn 日志，阻塞命令不记录
+func NewSlowLogHook(threshold time.Duration) Hook {
+	return HookFunc(func(ctx context.Context, info CommandInfo) {
+		if info.Blocking || info.Duration < threshold {
+			return
+		}
+		logger.Warnf("[Redis] slow command client=%s cmd=%s key=%s cmds=%d cost=%v err=%v",
+			info.Client, info.Name, info.Key, info.Cmds, info.Duration, info.Err)
+	})
+}
+
+// instrumentHook 挂到 go-redis 客户端上，将命令信息转发给全局 Hook
+type instrumentHook struct {
+	client string
+}
+
+func (h instrumentHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h instrumentHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		if list := hooks.Load(); list != nil {
+			args := cmd.Args()
+			info := CommandInfo{
+				Client:   h.client,
+				Name:     strings.ToLower(cmd.Name()),
+				Cmds:     1,
+				Duration: time.Since(start),
+				Err:      hookErr(err),
+			}
+			info.Key = commandKey(info.Name, args)
+			info.Blocking = isBlocking(info.Name, args)
+			for _, hk := range *list {
+				hk.AfterCommand(ctx, info)
+			}
+		}
+		return err
+	}
+}
+
+func (h instrumentHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		if list := hooks.Load(); list != nil {
+			info := CommandInfo{
+				Client:   h.client,
+				Name:     "pipeline",
+				Cmds:     len(cmds),
+				Duration: time.Since(start),
+				Err:      hookErr(err),
+			}
+			for _, cmd := range cmds {
+				name := strings.ToLower(cmd.Name())
+				if name == "multi" || name == "exec" {
+					continue
+				}
+				if info.Key = commandKey(name, cmd.Args()); info.Key != "" {
+					break
+				}
+			}
+			for _, hk := range *list {
+				hk.AfterCommand(ctx, info)
+			}
+		}
+		return err
+	}
+}
+
+// hookErr 按 wrapErr 转换，key 不存在不算错误
+func hookErr(err error) error {
+	err = wrapErr(err)
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// 没有 key 参数的命令
+var keylessCommands = map[string]bool{
+	"ping": true, "echo": true, "auth": true, "hello": true, "select": true, "quit": true,
+	"info": true, "time": true, "dbsize": true, "flushdb": true, "flushall": true,
+	"client": true, "cluster": true, "command": true, "config": true, "readonly": true,
+	"script": true, "scan": true, "multi": true, "exec": true, "discard": true, "unwatch": true,
+	"subscribe": true, "psubscribe": true, "unsubscribe": true, "punsubscribe": true,
+}
+
+// commandKey 取命令的第一个 key
+func commandKey(name string, args []any) string {
+	idx := 1
+	switch {
+	case keylessCommands[name]:
+		return ""
+	case name == "eval" || name == "evalsha" || name == "evalsha_ro" || name == "eval_ro" || name == "fcall":
+		// EVAL script numkeys key...
+		if len(args) < 4 || argString(args[2]) == "0" {
+			return ""
+		}
+		idx = 3
+	case name == "xread" || name == "xreadgroup":
+		for i, a := range args {
+			if strings.EqualFold(argString(a), "streams") {
+				idx = i + 1
+				break
+			}
+		}
+	}
+	if len(args) <= idx {
+		return ""
+	}
+	return argString(args[idx])
+}
+
+var blockingCommands = map[string]bool{
+	"blpop": true, "brpop": true, "brpoplpush": true, "blmove": true, "blmpop": true,
+	"bzpopmin": true, "bzpopmax": true, "bzmpop": true,
+}
+
+func isBlocking(name string, args []any) bool {
+	if blockingCommands[name] {
+		return true
+	}
+	if name == "xread" || name == "xreadgroup" {
+		for _, a := range args {
+			if strings.EqualFold(argString(a), "block") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func argString(a any) string {
+	switch v := a.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ---------------- HyperLogLog ----------------
+// 基数估算，标准误差约 0.81%，每个 key 最多 12KB
+
+// PFAdd 添加元素，基数估算值发生变化时返回 true
+func (c *RedisClient) PFAdd(key string, els ...any) (bool, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.PFAdd(ctx, key, els...).Result()
+	})
+	if err != nil {
+		return false, err
+	}
+	return res.(int64) == 1, nil
+}
+
+// PFCount 基数估算，多个 key 时返回并集的基数
+// cluster 模式下多个 key 需在同一 slot
+func (c *RedisClient) PFCount(keys ...string) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.PFCount(ctx, keys...).Result()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// PFMerge 合并多个 key 到 dest
+// cluster 模式下所有 key 需在同一 slot
+func (c *RedisClient) PFMerge(dest string, keys ...string) error {
+	_, err := c.do(func(ctx context.Context) (any, error) {
+		return nil, c.rdb.PFMerge(ctx, dest, keys...).Err()
+	})
+	return err
+}
+
+// ---------------- 按天去重计数 ----------------
+
+// UniqueCounter 按天统计去重数量（如日活玩家），每天一个 HyperLogLog
+// key 为 prefix:{name}:yyyymmdd，合并结果为 prefix:{name}:r:period，同一计数器的 key 落在同一 slot，可跨天合并
+type UniqueCounter struct {
+	cli  *RedisClient
+	base string
+	ttl  time.Duration
+}
+
+// NewUniqueCounter 创建按天去重计数器
+// ttl: 每天 key 的保留时长，<=0 不过期
+//
+//	dau := cache.GetDB(cache.DB0).NewUniqueCounter("stat", "dau", 90*24*time.Hour)
+//	dau.Add(uid)
+//	n, _ := dau.Count(time.Now())
+func (c *RedisClient) NewUniqueCounter(prefix, name string, ttl time.Duration) *UniqueCounter {
+	return &UniqueCounter{cli: c, base: prefix + keySep + "{" + name + "}" + keySep, ttl: ttl}
+}
+
+// DayKey 指定日期的 key，按 t 的时区取日期
+func (u *UniqueCounter) DayKey(t time.Time) string {
+	return u.base + t.Format("20060102")
+}
+
+// Add 记录到今天
+func (u *UniqueCounter) Add(els ...any) error {
+	return u.AddAt(time.Now(), els...)
+}
+
+// AddAt 记录到 t 所在的日期
+func (u *UniqueCounter) AddAt(t time.Time, els ...any) error {
+	key := u.DayKey(t)
+	if _, err := u.cli.PFAdd(key, els...); err != nil {
+		return err
+	}
+	if u.ttl > 0 {
+		return u.cli.Expire(key, u.ttl)
+	}
+	return nil
+}
+
+// Count t 所在日期的去重数量
+func (u *UniqueCounter) Count(t time.Time) (int64, error) {
+	return u.cli.PFCount(u.DayKey(t))
+}
+
+// CountRange [from, to] 日期范围内的去重数量（跨天去重），from 晚于 to 返回错误
+func (u *UniqueCounter) CountRange(from, to time.Time) (int64, error) {
+	keys, err := u.dayKeys(from, to)
+	if err != nil {
+		return 0, err
+	}
+	return u.cli.PFCount(keys...)
+}
+
+// Rollup 将 [from, to] 的每日数据合并到 prefix:{name}:r:period，返回合并后的去重数量
+// period 一般为周或月标识，如 "202401"；ttl<=0 不过期；from 晚于 to 返回错误
+func (u *UniqueCounter) Rollup(period string, from, to time.Time, ttl time.Duration) (int64, error) {
+	keys, err := u.dayKeys(from, to)
+	if err != nil {
+		return 0, err
+	}
+	dest := u.RollupKey(period)
+	if err := u.cli.PFMerge(dest, keys...); err != nil {
+		return 0, err
+	}
+	if ttl > 0 {
+		if err := u.cli.Expire(dest, ttl); err != nil {
+			return 0, err
+		}
+	}
+	return u.cli.PFCount(dest)
+}
+
+// RollupKey Rollup 结果所在的 key，与每日 key 使用不同的片段，避免 period 与日期同名时覆盖
+func (u *UniqueCounter) RollupKey(period string) string {
+	return u.base + "r" + keySep + period
+}
+
+func (u *UniqueCounter) dayKeys(from, to time.Time) ([]string, error) {
+	y, m, d := from.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, from.Location())
+	var keys []string
+	for !day.After(to) {
+		keys = append(keys, u.DayKey(day))
+		day = day.AddDate(0, 0, 1)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("cache: date range from %s after to %s", from.Format(time.DateOnly), to.Format(time.DateOnly))
+	}
+	return keys, nil
+}
@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tandy9527/js-util/logger"
+	"github.com/tandy9527/js-util/tools/str_tools"
+)
+
+// ErrInFlight 相同幂等 key 的请求正在处理中
+var ErrInFlight = errors.New("cache: idempotent request in flight")
+
+// 幂等记录以 hash 存储：s 状态（p 处理中，d 已完成），t 处理中持有者标识，r 编码后的结果
+
+// KEYS[1]=幂等 key  ARGV[1]=token  ARGV[2]=处理中 ttl(ms)
+// 返回 {1} 抢占成功；{0, state, response} 已存在
+var idemReserveScript = RegisterScript("cache.idem_reserve", `
+if redis.call('exists', KEYS[1]) == 0 then
+	redis.call('hset', KEYS[1], 's', 'p', 't', ARGV[1])
+	redis.call('pexpire', KEYS[1], ARGV[2])
+	return {1}
+end
+local v = redis.call('hmget', KEYS[1], 's', 'r')
+return {0, v[1] or '', v[2] or ''}
+`)
+
+// KEYS[1]=幂等 key  ARGV[1]=token  ARGV[2]=response  ARGV[3]=结果 ttl(ms)
+var idemCompleteScript = RegisterScript("cache.idem_complete", `
+if redis.call('hget', KEYS[1], 't') ~= ARGV[1] then
+	return 0
+end
+redis.call('hset', KEYS[1], 's', 'd', 'r', ARGV[2])
+redis.call('hdel', KEYS[1], 't')
+redis.call('pexpire', KEYS[1], ARGV[3])
+return 1
+`)
+
+// KEYS[1]=幂等 key  ARGV[1]=token  ARGV[2]=处理中 ttl(ms)，为 0 时删除
+var idemRenewScript = RegisterScript("cache.idem_renew", `
+if redis.call('hget', KEYS[1], 't') ~= ARGV[1] then
+	return 0
+end
+if ARGV[2] == '0' then
+	redis.call('del', KEYS[1])
+else
+	redis.call('pexpire', KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// IdempotencyOptions 幂等存储参数
+type IdempotencyOptions struct {
+	// TTL 结果保存时长，期间相同 key 的请求直接返回该结果，默认 24h
+	TTL time.Duration
+	// LockTTL 处理中状态的租约，处理期间每 LockTTL/3 续期，进程崩溃后到期自动释放，默认 30s
+	LockTTL time.Duration
+	// Wait 遇到处理中的重复请求时等待首个结果的最长时间，0 立即返回 ErrInFlight
+	Wait time.Duration
+}
+
+// Idempotency 幂等 key 存储，用于客户端重试时避免重复处理（支付、下注等）
+// 处理成功的结果保存 TTL；处理失败会释放 key，允许客户端重试
+type Idempotency struct {
+	cli    *RedisClient
+	prefix string
+	opt    IdempotencyOptions
+}
+
+// NewIdempotency 创建幂等存储，key 为 prefix:幂等key
+func (c *RedisClient) NewIdempotency(prefix string, opts ...IdempotencyOptions) *Idempotency {
+	var opt IdempotencyOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.TTL <= 0 {
+		opt.TTL = 24 * time.Hour
+	}
+	if opt.LockTTL <= 0 {
+		opt.LockTTL = defaultLockTTL
+	}
+	return &Idempotency{cli: c, prefix: prefix, opt: opt}
+}
+
+// Do 以 key 幂等执行 fn，返回结果及是否为重放的已保存结果
+// 首个请求执行 fn 并保存结果；重复请求返回已保存的结果，
+// 若首个请求仍在处理中，按 Wait 等待其结果，超时返回 ErrInFlight
+//
+//	resp, replayed, err := idem.Do(req.RequestID, func() ([]byte, error) {
+//		return pay(req)
+//	})
+func (i *Idempotency) Do(key string, fn func() ([]byte, error)) ([]byte, bool, error) {
+	key = i.prefix + keySep + key
+	deadline := time.Now().Add(i.opt.Wait)
+	for {
+		token := str_tools.RandLetterStr(20)
+		res, err := LuaStrings(idemReserveScript.Run(i.cli, []string{key}, token, i.opt.LockTTL.Milliseconds()))
+		if err != nil {
+			return nil, false, err
+		}
+		if res[0] == "1" {
+			resp, err := i.run(key, token, fn)
+			return resp, false, err
+		}
+		if res[1] == "d" {
+			return []byte(res[2]), true, nil
+		}
+		// 处理中（或刚被释放）
+		if !time.Now().Before(deadline) {
+			return nil, false, ErrInFlight
+		}
+		time.Sleep(loadPollInterval)
+	}
+}
+
+// run 执行 fn 并保存结果，执行期间续期处理中状态
+func (i *Idempotency) run(key, token string, fn func() ([]byte, error)) ([]byte, error) {
+	stop := make(chan struct{})
+	defer close(stop)
+	go i.renew(key, token, stop)
+
+	resp, err := fn()
+	if err != nil {
+		// 失败释放 key，允许重试
+		if _, rerr := idemRenewScript.Run(i.cli, []string{key}, token, 0); rerr != nil {
+			logger.Warnf("[Idempotency] release %s failed: %v", key, rerr)
+		}
+		return nil, err
+	}
+	ok, err := LuaBool(idemCompleteScript.Run(i.cli, []string{key}, token, resp, i.opt.TTL.Milliseconds()))
+	if err != nil {
+		// 结果已产生，只记录保存失败，重复请求可能被再次处理
+		logger.Errorf("[Idempotency] save %s failed: %v", key, err)
+	} else if !ok {
+		logger.Errorf("[Idempotency] %s lease lost before completion", key)
+	}
+	return resp, nil
+}
+
+func (i *Idempotency) renew(key, token string, stop chan struct{}) {
+	ticker := time.NewTicker(i.opt.LockTTL / 3)
+	defer ticker.Stop()
+	bg := i.cli.WithContext(context.Background())
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ok, err := LuaBool(idemRenewScript.Run(bg, []string{key}, token, i.opt.LockTTL.Milliseconds()))
+			if err != nil {
+				logger.Warnf("[Idempotency] renew %s failed: %v", key, err)
+			} else if !ok {
+				return
+			}
+		}
+	}
+}
+
+// Forget 删除 key 的记录，之后相同 key 的请求会重新处理
+func (i *Idempotency) Forget(key string) error {
+	return i.cli.Del(i.prefix + keySep + key)
+}
+
+// IdempotentDo 以 key 幂等执行 fn，结果使用客户端 Codec 编码保存
+//
+//	order, replayed, err := cache.IdempotentDo(idem, req.RequestID, func() (Order, error) {
+//		return createOrder(req)
+//	})
+func IdempotentDo[T any](i *Idempotency, key string, fn func() (T, error)) (T, bool, error) {
+	var val T
+	data, replayed, err := i.Do(key, func() ([]byte, error) {
+		v, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		val = v
+		return i.cli.Codec().Marshal(v)
+	})
+	if err != nil || !replayed {
+		return val, replayed, err
+	}
+	err = i.cli.Codec().Unmarshal(data, &val)
+	return val, true, err
+}
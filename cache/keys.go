@@ -0,0 +1,352 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeyType key 的 Redis 数据类型，取值与 TYPE 命令一致
+type KeyType string
+
+const (
+	TypeString KeyType = "string"
+	TypeHash   KeyType = "hash"
+	TypeList   KeyType = "list"
+	TypeSet    KeyType = "set"
+	TypeZSet   KeyType = "zset"
+	TypeStream KeyType = "stream"
+)
+
+const keySep = ":"
+
+var (
+	namespacesMu sync.Mutex
+	namespaces   = make(map[string]int) // prefix -> 创建次数，用于发现重复前缀
+
+	// 占位符 <name> 或 <name:int>
+	placeholderRe = regexp.MustCompile(`^<([a-zA-Z_][a-zA-Z0-9_]*)(?::(int|str))?>$`)
+)
+
+// Namespace key 命名空间，所有 key 以 "prefix:" 开头，不同服务使用不同前缀避免冲突
+type Namespace struct {
+	prefix string
+
+	mu    sync.Mutex
+	specs []*KeyDef
+	names map[string]*KeyDef
+}
+
+// NewNamespace 创建命名空间，prefix 一般为服务或游戏名
+// 同一进程内重复的 prefix 会在 Validate 时报错
+func NewNamespace(prefix string) *Namespace {
+	namespacesMu.Lock()
+	namespaces[prefix]++
+	namespacesMu.Unlock()
+	return &Namespace{prefix: prefix, names: make(map[string]*KeyDef)}
+}
+
+// Prefix 命名空间前缀
+func (n *Namespace) Prefix() string {
+	return n.prefix
+}
+
+// Key 拼接临时 key：prefix:seg1:seg2...，用于未注册的简单场景
+// 片段为空或包含 ":" "{" "}" 时 panic，属于编码错误
+func (n *Namespace) Key(segments ...any) string {
+	return n.join("", segments)
+}
+
+// Tagged 拼接带 hash tag 的 key：prefix:{tag}:seg1...，cluster 模式下相同 tag 的 key 落在同一 slot
+func (n *Namespace) Tagged(tag any, segments ...any) string {
+	return n.join("{"+mustSegment(tag)+"}", segments)
+}
+
+// join 拼接 prefix[:tag]:seg...
+func (n *Namespace) join(tag string, segments []any) string {
+	parts := make([]string, 0, len(segments)+2)
+	parts = append(parts, n.prefix)
+	if tag != "" {
+		parts = append(parts, tag)
+	}
+	for _, s := range segments {
+		parts = append(parts, mustSegment(s))
+	}
+	return strings.Join(parts, keySep)
+}
+
+// KeySpec key 声明
+type KeySpec struct {
+	Name       string        // 逻辑名，命名空间内唯一
+	Pattern    string        // 模板，如 "player:<uid:int>:profile"，占位符类型 int / str，默认 str
+	Type       KeyType       // 数据类型
+	TTL        time.Duration // 默认过期时间
+	Persistent bool          // 永不过期，TTL 为 0 时必须显式声明
+	HashTag    string        // 作为 hash tag 的占位符名，cluster 下同一取值的 key 落在同一 slot
+}
+
+// KeyDef 已注册的 key 定义
+type KeyDef struct {
+	ns       *Namespace
+	spec     KeySpec
+	segments []keySegment
+	nParams  int
+	errs     []error
+}
+
+type keySegment struct {
+	literal string
+	param   string
+	isInt   bool
+	isTag   bool
+}
+
+// Register 注册 key 声明，声明错误在 Validate 时统一返回
+func (n *Namespace) Register(spec KeySpec) *KeyDef {
+	d := &KeyDef{ns: n, spec: spec}
+	d.parse()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.names[spec.Name]; ok {
+		d.errs = append(d.errs, fmt.Errorf("duplicate name"))
+	} else {
+		n.names[spec.Name] = d
+	}
+	n.specs = append(n.specs, d)
+	return d
+}
+
+func (d *KeyDef) parse() {
+	spec := d.spec
+	if spec.Name == "" {
+		d.errs = append(d.errs, fmt.Errorf("empty name"))
+	}
+	if spec.Pattern == "" {
+		d.errs = append(d.errs, fmt.Errorf("empty pattern"))
+		return
+	}
+	switch spec.Type {
+	case TypeString, TypeHash, TypeList, TypeSet, TypeZSet, TypeStream:
+	default:
+		d.errs = append(d.errs, fmt.Errorf("unknown type %q", spec.Type))
+	}
+	if spec.TTL < 0 {
+		d.errs = append(d.errs, fmt.Errorf("negative ttl"))
+	}
+	if spec.TTL == 0 && !spec.Persistent {
+		d.errs = append(d.errs, fmt.Errorf("missing ttl, set TTL or Persistent"))
+	}
+
+	tagFound := spec.HashTag == ""
+	seen := make(map[string]bool)
+	for _, part := range splitPattern(spec.Pattern) {
+		m := placeholderRe.FindStringSubmatch(part)
+		if m == nil {
+			if part == "" || strings.ContainsAny(part, "<>{}*?[]") {
+				d.errs = append(d.errs, fmt.Errorf("invalid segment %q", part))
+			}
+			d.segments = append(d.segments, keySegment{literal: part})
+			continue
+		}
+		if seen[m[1]] {
+			d.errs = append(d.errs, fmt.Errorf("duplicate placeholder %q", m[1]))
+		}
+		seen[m[1]] = true
+		seg := keySegment{param: m[1], isInt: m[2] == "int", isTag: m[1] == spec.HashTag}
+		tagFound = tagFound || seg.isTag
+		d.segments = append(d.segments, seg)
+		d.nParams++
+	}
+	if !tagFound {
+		d.errs = append(d.errs, fmt.Errorf("hash tag placeholder %q not in pattern", spec.HashTag))
+	}
+}
+
+// Key 按占位符顺序填入参数生成完整 key
+// 参数个数或类型不匹配、参数为空或包含 ":" "{" "}" 时 panic，属于编码错误
+func (d *KeyDef) Key(args ...any) string {
+	if len(args) != d.nParams {
+		panic(fmt.Sprintf("cache: key %s expects %d args, got %d", d.spec.Name, d.nParams, len(args)))
+	}
+	parts := make([]string, 0, len(d.segments)+1)
+	parts = append(parts, d.ns.prefix)
+	i := 0
+	for _, seg := range d.segments {
+		if seg.param == "" {
+			parts = append(parts, seg.literal)
+			continue
+		}
+		arg := args[i]
+		i++
+		if seg.isInt && !isInteger(arg) {
+			panic(fmt.Sprintf("cache: key %s segment <%s> expects int, got %T", d.spec.Name, seg.param, arg))
+		}
+		s := formatSegment(arg)
+		if err := checkSegment(s); err != nil {
+			panic(fmt.Sprintf("cache: key %s segment <%s> %v", d.spec.Name, seg.param, err))
+		}
+		if seg.isTag {
+			s = "{" + s + "}"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, keySep)
+}
+
+// Match SCAN 用的匹配模式，占位符替换为 *
+func (d *KeyDef) Match() string {
+	parts := make([]string, 0, len(d.segments)+1)
+	parts = append(parts, d.ns.prefix)
+	for _, seg := range d.segments {
+		if seg.param == "" {
+			parts = append(parts, seg.literal)
+		} else {
+			parts = append(parts, "*")
+		}
+	}
+	return strings.Join(parts, keySep)
+}
+
+// Name 逻辑名
+func (d *KeyDef) Name() string { return d.spec.Name }
+
+// Type 数据类型
+func (d *KeyDef) Type() KeyType { return d.spec.Type }
+
+// TTL 默认过期时间，Persistent 时为 0
+func (d *KeyDef) TTL() time.Duration { return d.spec.TTL }
+
+// Validate 校验命名空间内所有声明，启动时调用
+// 检查：重复前缀、重复逻辑名、缺少 TTL、未知类型、非法片段、模板冲突
+func (n *Namespace) Validate() error {
+	var errs []error
+	namespacesMu.Lock()
+	if namespaces[n.prefix] > 1 {
+		errs = append(errs, fmt.Errorf("cache: namespace prefix %q declared %d times", n.prefix, namespaces[n.prefix]))
+	}
+	namespacesMu.Unlock()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	shapes := make(map[string]string)
+	for _, d := range n.specs {
+		for _, err := range d.errs {
+			errs = append(errs, fmt.Errorf("cache: key %s (%s): %w", d.spec.Name, d.spec.Pattern, err))
+		}
+		// 字面量相同、占位符位置相同的模板会生成相同的 key
+		s := d.Match()
+		if other, ok := shapes[s]; ok && other != d.spec.Name {
+			errs = append(errs, fmt.Errorf("cache: key %s conflicts with %s", d.spec.Name, other))
+		}
+		shapes[s] = d.spec.Name
+	}
+	return errors.Join(errs...)
+}
+
+// Check 抽样检查 Redis 中已存在的 key 是否与声明的类型、TTL 一致，每个声明最多检查 sample 个
+func (n *Namespace) Check(c *RedisClient, sample int64) error {
+	n.mu.Lock()
+	specs := append([]*KeyDef(nil), n.specs...)
+	n.mu.Unlock()
+
+	var errs []error
+	for _, d := range specs {
+		res, err := c.do(func(ctx context.Context) (any, error) {
+			keys, _, err := c.rdb.Scan(ctx, 0, d.Match(), sample).Result()
+			return keys, err
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range res.([]string) {
+			typ, err := c.do(func(ctx context.Context) (any, error) {
+				return c.rdb.Type(ctx, key).Result()
+			})
+			if err != nil {
+				return err
+			}
+			if KeyType(typ.(string)) != d.spec.Type {
+				errs = append(errs, fmt.Errorf("cache: key %s is %s, declared %s as %s", key, typ, d.spec.Name, d.spec.Type))
+				continue
+			}
+			if !d.spec.Persistent {
+				ttl, err := c.TTL(key)
+				if err != nil {
+					return err
+				}
+				if ttl == -1 {
+					errs = append(errs, fmt.Errorf("cache: key %s has no ttl, declared %s with ttl %v", key, d.spec.Name, d.spec.TTL))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// splitPattern 按 ":" 切分模板，占位符 <...> 内的 ":" 不切分
+func splitPattern(pattern string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range pattern {
+		switch r {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case ':':
+			if depth == 0 {
+				parts = append(parts, pattern[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, pattern[start:])
+}
+
+func isInteger(v any) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return true
+	}
+	return false
+}
+
+// checkSegment 片段不能为空，不能包含分隔符和 hash tag 括号，否则会与其他 key 冲突或改变 slot
+func checkSegment(s string) error {
+	if s == "" {
+		return errors.New("is empty")
+	}
+	if strings.ContainsAny(s, keySep+"{}") {
+		return fmt.Errorf("%q contains ':', '{' or '}'", s)
+	}
+	return nil
+}
+
+func mustSegment(v any) string {
+	s := formatSegment(v)
+	if err := checkSegment(s); err != nil {
+		panic(fmt.Sprintf("cache: key segment %v", err))
+	}
+	return s
+}
+
+func formatSegment(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case int:
+		return strconv.Itoa(s)
+	case int64:
+		return strconv.FormatInt(s, 10)
+	case uint64:
+		return strconv.FormatUint(s, 10)
+	default:
+		return fmt.Sprint(v)
+	}
+}
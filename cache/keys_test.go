@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		want    []string
+	}{
+		{"player", []string{"player"}},
+		{"player:<uid:int>:profile", []string{"player", "<uid:int>", "profile"}},
+		{"<a:str>:<b>", []string{"<a:str>", "<b>"}},
+		{"a::b", []string{"a", "", "b"}},
+		{"a:", []string{"a", ""}},
+	}
+	for _, c := range cases {
+		if got := splitPattern(c.pattern); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("splitPattern(%q) = %q, want %q", c.pattern, got, c.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name string
+		spec KeySpec
+		err  string // 期望错误包含的内容，为空表示无错误
+	}{
+		{"ok", KeySpec{Name: "p", Pattern: "player:<uid:int>:profile", Type: TypeHash, TTL: time.Hour}, ""},
+		{"persistent", KeySpec{Name: "p", Pattern: "cfg", Type: TypeString, Persistent: true}, ""},
+		{"hash tag", KeySpec{Name: "p", Pattern: "room:<rid>", Type: TypeSet, TTL: time.Hour, HashTag: "rid"}, ""},
+		{"empty name", KeySpec{Pattern: "a", Type: TypeString, TTL: time.Hour}, "empty name"},
+		{"empty pattern", KeySpec{Name: "p", Type: TypeString, TTL: time.Hour}, "empty pattern"},
+		{"unknown type", KeySpec{Name: "p", Pattern: "a", Type: "json", TTL: time.Hour}, "unknown type"},
+		{"negative ttl", KeySpec{Name: "p", Pattern: "a", Type: TypeString, TTL: -time.Second}, "negative ttl"},
+		{"missing ttl", KeySpec{Name: "p", Pattern: "a", Type: TypeString}, "missing ttl"},
+		{"empty segment", KeySpec{Name: "p", Pattern: "a::b", Type: TypeString, TTL: time.Hour}, "invalid segment"},
+		{"wildcard segment", KeySpec{Name: "p", Pattern: "a:*", Type: TypeString, TTL: time.Hour}, "invalid segment"},
+		{"brace segment", KeySpec{Name: "p", Pattern: "{a}:b", Type: TypeString, TTL: time.Hour}, "invalid segment"},
+		{"bad placeholder", KeySpec{Name: "p", Pattern: "a:<uid:float>", Type: TypeString, TTL: time.Hour}, "invalid segment"},
+		{"duplicate placeholder", KeySpec{Name: "p", Pattern: "<uid>:<uid>", Type: TypeString, TTL: time.Hour}, "duplicate placeholder"},
+		{"missing hash tag", KeySpec{Name: "p", Pattern: "a:<uid>", Type: TypeString, TTL: time.Hour, HashTag: "rid"}, "hash tag"},
+	}
+	for _, c := range cases {
+		d := &KeyDef{spec: c.spec}
+		d.parse()
+		if c.err == "" {
+			if len(d.errs) > 0 {
+				t.Errorf("%s: unexpected errors %v", c.name, d.errs)
+			}
+			continue
+		}
+		found := false
+		for _, err := range d.errs {
+			found = found || strings.Contains(err.Error(), c.err)
+		}
+		if !found {
+			t.Errorf("%s: errors %v, want %q", c.name, d.errs, c.err)
+		}
+	}
+}
+
+func TestKeyDefKey(t *testing.T) {
+	ns := NewNamespace(uniquePrefix("test_keydef"))
+	p := ns.Prefix()
+	profile := ns.Register(KeySpec{Name: "profile", Pattern: "player:<uid:int>:profile", Type: TypeHash, TTL: time.Hour})
+	room := ns.Register(KeySpec{Name: "room", Pattern: "room:<rid>:members", Type: TypeSet, TTL: time.Hour, HashTag: "rid"})
+
+	if got, want := profile.Key(42), p+":player:42:profile"; got != want {
+		t.Errorf("Key = %q, want %q", got, want)
+	}
+	if got, want := room.Key("r1"), p+":room:{r1}:members"; got != want {
+		t.Errorf("Key = %q, want %q", got, want)
+	}
+	if got, want := profile.Match(), p+":player:*:profile"; got != want {
+		t.Errorf("Match = %q, want %q", got, want)
+	}
+
+	panics := []struct {
+		name string
+		fn   func()
+	}{
+		{"arg count", func() { profile.Key() }},
+		{"arg type", func() { profile.Key("42") }},
+		{"empty", func() { room.Key("") }},
+		{"separator", func() { room.Key("a:b") }},
+		{"brace", func() { room.Key("{a}") }},
+		{"namespace separator", func() { ns.Key("a", "b:c") }},
+		{"namespace brace", func() { ns.Tagged("a}") }},
+	}
+	for _, p := range panics {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", p.name)
+				}
+			}()
+			p.fn()
+		}()
+	}
+
+	if got, want := ns.Tagged(7, "x"), p+":{7}:x"; got != want {
+		t.Errorf("Tagged = %q, want %q", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ns := NewNamespace(uniquePrefix("test_validate"))
+	ns.Register(KeySpec{Name: "a", Pattern: "user:<uid:int>", Type: TypeString, TTL: time.Hour})
+	ns.Register(KeySpec{Name: "b", Pattern: "user:<name>:info", Type: TypeHash, TTL: time.Hour})
+	if err := ns.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	ns.Register(KeySpec{Name: "c", Pattern: "user:<name>", Type: TypeString, TTL: time.Hour})
+	ns.Register(KeySpec{Name: "a", Pattern: "other", Type: TypeString, TTL: time.Hour})
+	ns.Register(KeySpec{Name: "d", Pattern: "d", Type: TypeString})
+	err := ns.Validate()
+	if err == nil {
+		t.Fatal("Validate: expected error")
+	}
+	for _, want := range []string{"conflicts with a", "duplicate name", "missing ttl"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate error %q missing %q", err, want)
+		}
+	}
+
+	prefix := uniquePrefix("test_validate_dup")
+	NewNamespace(prefix)
+	dup := NewNamespace(prefix)
+	if err := dup.Validate(); err == nil || !strings.Contains(err.Error(), "declared 2 times") {
+		t.Errorf("Validate duplicate prefix: %v", err)
+	}
+}
+
+// uniquePrefix 前缀登记是进程级的，-count>1 时每次使用不同前缀
+func uniquePrefix(base string) string {
+	return base + "_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+}
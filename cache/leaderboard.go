@@ -0,0 +1,365 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ScorePolicy 提交分数的合并策略
+type ScorePolicy string
+
+const (
+	PolicyMax    ScorePolicy = "max"    // 保留最高分
+	PolicySum    ScorePolicy = "sum"    // 累加
+	PolicyLatest ScorePolicy = "latest" // 以最后一次为准
+)
+
+// Period 榜单周期
+type Period int
+
+const (
+	PeriodNone    Period = iota // 不轮换
+	PeriodDaily                 // 按天
+	PeriodWeekly                // 按周（ISO 周，周一开始）
+	PeriodMonthly               // 按月
+)
+
+// 同分按达成时间先后排序：zset score = 分数*tieScale + (tieScale-1-距周期开始秒数)
+// tieScale 覆盖约 115 天，分数绝对值需小于 maxFloatInt/tieScale 才能保证精度
+const (
+	tieScale    = 10000000
+	maxFloatInt = 1 << 53 // float64 可精确表示的整数上限
+)
+
+// KEYS[1]=榜单  ARGV[1]=策略 ARGV[2]=分数 ARGV[3]=tieScale ARGV[4]=时间部分 ARGV[5]=成员 ARGV[6]=分数绝对值上限
+// 累加后超出上限返回错误，不修改榜单
+var leaderboardSubmitScript = RegisterScript("cache.leaderboard_submit", `
+local scale = tonumber(ARGV[3])
+local raw = tonumber(ARGV[2])
+local cur = redis.call('ZSCORE', KEYS[1], ARGV[5])
+if cur then
+	local old = math.floor(tonumber(cur) / scale)
+	if ARGV[1] == 'max' then
+		if raw <= old then
+			return old
+		end
+	elseif ARGV[1] == 'sum' then
+		raw = old + raw
+	end
+end
+if math.abs(raw) >= tonumber(ARGV[6]) then
+	return redis.error_reply('ERR leaderboard score out of range')
+end
+redis.call('ZADD', KEYS[1], raw * scale + tonumber(ARGV[4]), ARGV[5])
+return raw
+`)
+
+// RankEntry 排名条目，Rank 从 1 开始
+type RankEntry struct {
+	Member string
+	Score  int64
+	Rank   int64
+}
+
+// Leaderboard 排行榜
+// 周期榜单按当前时间自动落到对应周期的 key（name:周期id），历史周期可通过 Board 访问，
+// 结束的周期可通过 Archive 归档
+// 周期榜单同分时先达成者排名靠前；PeriodNone 的榜单设置 Epoch 后同样按达成时间排序，否则同分按成员字典序，
+// 自定义赛季可用 PeriodNone + 赛季名区分，Epoch 取赛季开始时间
+// cluster 模式下 name 需使用 {hash tag}，归档时 RENAME 要求新旧 key 在同一 slot
+type Leaderboard struct {
+	cli    *RedisClient
+	name   string
+	policy ScorePolicy
+	period Period
+	opt    LeaderboardOptions
+}
+
+// LeaderboardOptions 排行榜参数
+type LeaderboardOptions struct {
+	// Epoch PeriodNone 榜单同分排序的起点，如赛季创建时间，之后约 115 天内按达成时间打破同分
+	// 为零值时不按时间排序；设置与否决定分数编码，已有数据的榜单不能再修改
+	Epoch time.Time
+}
+
+// NewLeaderboard 创建排行榜
+func (c *RedisClient) NewLeaderboard(name string, policy ScorePolicy, period Period, opts ...LeaderboardOptions) *Leaderboard {
+	var opt LeaderboardOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	return &Leaderboard{cli: c, name: name, policy: policy, period: period, opt: opt}
+}
+
+// PeriodID 时间 t 所在的周期 id：20261017 / 2026W42 / 202610，PeriodNone 返回空
+func (l *Leaderboard) PeriodID(t time.Time) string {
+	switch l.period {
+	case PeriodDaily:
+		return t.Format("20060102")
+	case PeriodWeekly:
+		y, w := t.ISOWeek()
+		return fmt.Sprintf("%dW%02d", y, w)
+	case PeriodMonthly:
+		return t.Format("200601")
+	default:
+		return ""
+	}
+}
+
+// periodStart 时间 t 所在周期的开始时间，PeriodNone 返回 Epoch
+func (l *Leaderboard) periodStart(t time.Time) time.Time {
+	y, m, d := t.Date()
+	switch l.period {
+	case PeriodDaily:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	case PeriodWeekly:
+		offset := (int(t.Weekday()) + 6) % 7 // 周一为 0
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	case PeriodMonthly:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return l.opt.Epoch
+	}
+}
+
+// Current 当前周期的榜单
+func (l *Leaderboard) Current() *Board {
+	now := time.Now()
+	return l.board(l.key(l.PeriodID(now)), l.periodStart(now))
+}
+
+// Board 指定周期的榜单，周期开始时间由 periodID 解析，无法解析时该榜单不能 Submit
+func (l *Leaderboard) Board(periodID string) *Board {
+	return l.board(l.key(periodID), l.parsePeriod(periodID))
+}
+
+// parsePeriod 周期 id 对应的开始时间（本地时区），与 periodStart 一致，无法解析返回零值
+func (l *Leaderboard) parsePeriod(periodID string) time.Time {
+	switch l.period {
+	case PeriodDaily:
+		t, _ := time.ParseInLocation("20060102", periodID, time.Local)
+		return t
+	case PeriodWeekly:
+		var y, w int
+		if n, err := fmt.Sscanf(periodID, "%dW%d", &y, &w); err != nil || n != 2 || w < 1 || w > 53 {
+			return time.Time{}
+		}
+		// 1 月 4 日总在第 1 周
+		jan4 := time.Date(y, 1, 4, 0, 0, 0, 0, time.Local)
+		start := l.periodStart(jan4).AddDate(0, 0, (w-1)*7)
+		if l.PeriodID(start) != periodID {
+			return time.Time{}
+		}
+		return start
+	case PeriodMonthly:
+		t, _ := time.ParseInLocation("200601", periodID, time.Local)
+		return t
+	default:
+		return l.opt.Epoch
+	}
+}
+
+// Archived 已归档的榜单，只读，Submit 返回错误
+func (l *Leaderboard) Archived(periodID string) *Board {
+	return l.board(l.archiveKey(periodID), time.Time{})
+}
+
+func (l *Leaderboard) board(key string, epoch time.Time) *Board {
+	tie := l.period != PeriodNone || !l.opt.Epoch.IsZero()
+	return &Board{cli: l.cli, key: key, policy: l.policy, epoch: epoch, tie: tie}
+}
+
+func (l *Leaderboard) key(periodID string) string {
+	if periodID == "" {
+		return l.name
+	}
+	return l.name + ":" + periodID
+}
+
+func (l *Leaderboard) archiveKey(periodID string) string {
+	return l.name + ":archive:" + periodID
+}
+
+// Submit 向当前周期提交分数，返回合并后的分数
+func (l *Leaderboard) Submit(member string, score int64) (int64, error) {
+	return l.Current().Submit(member, score)
+}
+
+// Rank 当前周期中成员的排名
+func (l *Leaderboard) Rank(member string) (*RankEntry, error) {
+	return l.Current().Rank(member)
+}
+
+// Top 当前周期分页排名
+func (l *Leaderboard) Top(page, size int64) ([]RankEntry, error) {
+	return l.Current().Top(page, size)
+}
+
+// AroundMe 当前周期中成员前后各 n 名
+func (l *Leaderboard) AroundMe(member string, n int64) ([]RankEntry, error) {
+	return l.Current().AroundMe(member, n)
+}
+
+// Archive 归档已结束的周期：只保留前 keep 名（<=0 全部保留），移动到归档 key 并设置过期时间 ttl（<=0 不过期）
+// 周期榜单不存在返回 ErrNotFound
+func (l *Leaderboard) Archive(periodID string, keep int64, ttl time.Duration) error {
+	src := l.key(periodID)
+	ok, err := l.cli.Exists(src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	dst := l.archiveKey(periodID)
+	_, err = l.cli.TxPipelined(func(ctx context.Context, pipe redis.Pipeliner) error {
+		if keep > 0 {
+			pipe.ZRemRangeByRank(ctx, src, 0, -(keep + 1))
+		}
+		pipe.Rename(ctx, src, dst)
+		if ttl > 0 {
+			pipe.Expire(ctx, dst, ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+// ArchivePrevious 归档上一个周期，供定时任务在周期切换后调用
+func (l *Leaderboard) ArchivePrevious(keep int64, ttl time.Duration) error {
+	if l.period == PeriodNone {
+		return fmt.Errorf("cache: leaderboard %s has no period", l.name)
+	}
+	prev := l.periodStart(time.Now()).Add(-time.Second)
+	return l.Archive(l.PeriodID(prev), keep, ttl)
+}
+
+// Board 单个周期的榜单
+type Board struct {
+	cli    *RedisClient
+	key    string
+	policy ScorePolicy
+	epoch  time.Time // 同分排序的起点，按时间打破同分但为零值时不能 Submit
+	tie    bool      // 是否按时间打破同分
+}
+
+// Key 榜单 key
+func (b *Board) Key() string {
+	return b.key
+}
+
+func (b *Board) scale() float64 {
+	if b.tie {
+		return tieScale
+	}
+	return 1
+}
+
+// Submit 提交分数，按策略合并，返回合并后的分数
+func (b *Board) Submit(member string, score int64) (int64, error) {
+	limit := int64(maxFloatInt / b.scale())
+	if score >= limit || score <= -limit {
+		return 0, fmt.Errorf("cache: leaderboard score %d out of range", score)
+	}
+	var tsPart int64
+	if b.tie {
+		if b.epoch.IsZero() {
+			// 不知道周期起点，写入的时间部分会与 Current 不一致
+			return 0, fmt.Errorf("cache: leaderboard %s is read-only", b.key)
+		}
+		elapsed := int64(time.Since(b.epoch) / time.Second)
+		tsPart = tieScale - 1 - min(max(elapsed, 0), tieScale-1)
+	}
+	res, err := leaderboardSubmitScript.Run(b.cli, []string{b.key},
+		string(b.policy), score, int64(b.scale()), tsPart, member, limit)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.(int64)
+	return n, nil
+}
+
+// Remove 移除成员
+func (b *Board) Remove(members ...any) error {
+	_, err := b.cli.ZRem(b.key, members...)
+	return err
+}
+
+// Count 成员数量
+func (b *Board) Count() (int64, error) {
+	return b.cli.ZCard(b.key)
+}
+
+// Score 成员分数
+func (b *Board) Score(member string) (int64, error) {
+	s, err := b.cli.ZScore(b.key, member)
+	if err != nil {
+		return 0, err
+	}
+	return b.decode(s), nil
+}
+
+// Rank 成员排名，不在榜上返回 ErrNotFound
+func (b *Board) Rank(member string) (*RankEntry, error) {
+	var rank *redis.IntCmd
+	var score *redis.FloatCmd
+	_, err := b.cli.Pipelined(func(ctx context.Context, pipe redis.Pipeliner) error {
+		rank = pipe.ZRevRank(ctx, b.key, member)
+		score = pipe.ZScore(ctx, b.key, member)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &RankEntry{Member: member, Score: b.decode(score.Val()), Rank: rank.Val() + 1}, nil
+}
+
+// Top 分页排名，page 从 1 开始，size <= 0 返回空
+func (b *Board) Top(page, size int64) ([]RankEntry, error) {
+	if size <= 0 {
+		return nil, nil
+	}
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * size
+	return b.rangeByRank(start, start+size-1)
+}
+
+// AroundMe 成员前后各 n 名（含自己），不在榜上返回 ErrNotFound，n < 0 按 0 处理
+func (b *Board) AroundMe(member string, n int64) ([]RankEntry, error) {
+	n = max(n, 0)
+	res, err := b.cli.do(func(ctx context.Context) (any, error) {
+		return b.cli.rdb.ZRevRank(ctx, b.key, member).Result()
+	})
+	if err != nil {
+		return nil, err
+	}
+	r := res.(int64)
+	return b.rangeByRank(max(r-n, 0), r+n)
+}
+
+func (b *Board) rangeByRank(start, stop int64) ([]RankEntry, error) {
+	zs, err := b.cli.ZRevRangeWithScores(b.key, start, stop)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]RankEntry, 0, len(zs))
+	for i, z := range zs {
+		member, _ := z.Member.(string)
+		entries = append(entries, RankEntry{
+			Member: member,
+			Score:  b.decode(z.Score),
+			Rank:   start + int64(i) + 1,
+		})
+	}
+	return entries, nil
+}
+
+func (b *Board) decode(s float64) int64 {
+	return int64(math.Floor(s / b.scale()))
+}
@@ -0,0 +1,286 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tandy9527/js-util/logger"
+)
+
+// LoadOptions GetOrLoad 可选参数
+type LoadOptions struct {
+	// NegativeTTL loader 返回 ErrNotFound 时缓存空结果的时长，0 不缓存
+	NegativeTTL time.Duration
+	// LockTTL >0 时未命中先抢占 Redis 锁，只有一个实例回源，防止跨实例击穿
+	LockTTL time.Duration
+	// LockWait 未抢到锁时等待其他实例回填的最长时间，默认 LockTTL
+	LockWait time.Duration
+	// Beta 提前刷新系数（XFetch 算法），越大越早刷新，0 关闭，通常取 1
+	Beta float64
+}
+
+// 缓存条目以 hash 存储，便于记录回源耗时和过期时间
+const (
+	entryValue  = "v" // 编码后的值
+	entryDelta  = "d" // 回源耗时 ms
+	entryExpire = "e" // 逻辑过期时间 unix ms
+	entryMiss   = "n" // 空结果标记
+)
+
+const loadPollInterval = 50 * time.Millisecond
+
+var loadGroup flightGroup
+
+// GetOrLoad 旁路缓存：命中直接返回，未命中调用 loader 回源并写入缓存
+// 同一进程内对同一 key 的并发未命中只会回源一次；
+// loader 返回 ErrNotFound 时按 NegativeTTL 缓存空结果，其余错误不缓存
+// 条目以 hash 存储，不要与 Get/GetAs 混用同一个 key
+//
+//	p, err := cache.GetOrLoad(cli, "player:1", time.Hour, func() (Player, error) {
+//		return loadPlayer(1)
+//	}, cache.LoadOptions{NegativeTTL: time.Minute, Beta: 1})
+func GetOrLoad[T any](c *RedisClient, key string, ttl time.Duration, loader func() (T, error), opts ...LoadOptions) (T, error) {
+	var opt LoadOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	var zero T
+
+	entry, err := c.readEntry(key)
+	if err != nil && !IsNotFound(err) {
+		return zero, err
+	}
+	if err == nil {
+		if entry.miss {
+			return zero, ErrNotFound
+		}
+		var val T
+		if err := c.Codec().Unmarshal(entry.value, &val); err != nil {
+			return zero, err
+		}
+		if opt.Beta > 0 && entry.shouldRefresh(opt.Beta) {
+			// 返回旧值，后台异步刷新
+			bg := c.WithContext(context.Background())
+			go func() {
+				// 后台刷新没有调用方可以接住 loader 的 panic，记录后丢弃，避免进程退出
+				defer func() {
+					if r := recover(); r != nil {
+						logger.Errorf("[GetOrLoad] early refresh %s panic: %v", key, r)
+					}
+				}()
+				if _, err := bg.load(key, ttl, opt, wrapLoader(loader)); err != nil && !IsNotFound(err) {
+					logger.Warnf("[GetOrLoad] early refresh %s failed: %v", key, err)
+				}
+			}()
+		}
+		return val, nil
+	}
+
+	res, err := c.load(key, ttl, opt, wrapLoader(loader))
+	if err != nil {
+		return zero, err
+	}
+	var val T
+	if err := c.Codec().Unmarshal(res, &val); err != nil {
+		return zero, err
+	}
+	return val, nil
+}
+
+func wrapLoader[T any](loader func() (T, error)) func() (any, error) {
+	return func() (any, error) { return loader() }
+}
+
+// load 回源并写缓存，返回编码后的值
+func (c *RedisClient) load(key string, ttl time.Duration, opt LoadOptions, loader func() (any, error)) ([]byte, error) {
+	res, err := loadGroup.Do(fmt.Sprintf("%p:%s", c.rdb, key), func() (any, error) {
+		if opt.LockTTL > 0 {
+			lock := c.NewLock(key+":loading", opt.LockTTL, "")
+			ok, err := lock.TryLock()
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				defer lock.Unlock()
+			} else if entry, ok := c.waitEntry(key, opt); ok {
+				// 其他实例已回填
+				if entry.miss {
+					return nil, ErrNotFound
+				}
+				return entry.value, nil
+			}
+		}
+
+		start := time.Now()
+		val, err := loader()
+		delta := time.Since(start)
+		if IsNotFound(err) {
+			if opt.NegativeTTL > 0 {
+				if err := c.writeEntry(key, nil, delta, opt.NegativeTTL, true); err != nil {
+					logger.Warnf("[GetOrLoad] cache miss %s failed: %v", key, err)
+				}
+			}
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := c.Codec().Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.writeEntry(key, data, delta, ttl, false); err != nil {
+			logger.Warnf("[GetOrLoad] cache %s failed: %v", key, err)
+		}
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.([]byte), nil
+}
+
+// waitEntry 等待其他实例回填缓存
+func (c *RedisClient) waitEntry(key string, opt LoadOptions) (*cacheEntry, bool) {
+	wait := opt.LockWait
+	if wait <= 0 {
+		wait = opt.LockTTL
+	}
+	deadline := time.Now().Add(wait)
+	for time.Now().Before(deadline) {
+		time.Sleep(loadPollInterval)
+		if entry, err := c.readEntry(key); err == nil {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+type cacheEntry struct {
+	value  []byte
+	delta  time.Duration
+	expire time.Time
+	miss   bool
+}
+
+// shouldRefresh XFetch：now - delta*beta*ln(rand) >= expire 时提前刷新
+func (e *cacheEntry) shouldRefresh(beta float64) bool {
+	if e.expire.IsZero() {
+		return false
+	}
+	gap := time.Duration(float64(e.delta) * beta * -math.Log(rand.Float64()))
+	return !time.Now().Add(gap).Before(e.expire)
+}
+
+func (c *RedisClient) readEntry(key string) (*cacheEntry, error) {
+	m, err := c.HGetAll(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(m) == 0 {
+		return nil, ErrNotFound
+	}
+	entry := &cacheEntry{
+		value: []byte(m[entryValue]),
+		miss:  m[entryMiss] == "1",
+	}
+	if d, err := strconv.ParseInt(m[entryDelta], 10, 64); err == nil {
+		entry.delta = time.Duration(d) * time.Millisecond
+	}
+	if e, err := strconv.ParseInt(m[entryExpire], 10, 64); err == nil {
+		entry.expire = time.UnixMilli(e)
+	}
+	return entry, nil
+}
+
+func (c *RedisClient) writeEntry(key string, value []byte, delta, ttl time.Duration, miss bool) error {
+	fields := map[string]any{
+		entryValue: value,
+		entryDelta: delta.Milliseconds(),
+	}
+	if miss {
+		fields[entryMiss] = "1"
+	}
+	if ttl > 0 {
+		fields[entryExpire] = time.Now().Add(ttl).UnixMilli()
+	}
+	_, err := c.TxPipelined(func(ctx context.Context, pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key, fields)
+		if ttl > 0 {
+			pipe.PExpire(ctx, key, ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+// flightGroup 进程内合并同一 key 的并发调用
+type flightGroup struct {
+	mu sync.Mutex
+	m  map[string]*flightCall
+}
+
+type flightCall struct {
+	wg  sync.WaitGroup
+	val any
+	err error
+	// panic fn panic 时的值，等待者收到同样的 panic，避免永久阻塞
+	panic any
+}
+
+// flightPanic 包装 fn 的 panic 值和堆栈
+type flightPanic struct {
+	value any
+	stack []byte
+}
+
+func (p *flightPanic) Error() string {
+	return fmt.Sprintf("%v\n\n%s", p.value, p.stack)
+}
+
+func (g *flightGroup) Do(key string, fn func() (any, error)) (any, error) {
+	g.mu.Lock()
+	if g.m == nil {
+		g.m = make(map[string]*flightCall)
+	}
+	if call, ok := g.m[key]; ok {
+		g.mu.Unlock()
+		call.wg.Wait()
+		if call.panic != nil {
+			panic(call.panic)
+		}
+		return call.val, call.err
+	}
+	call := &flightCall{}
+	call.wg.Add(1)
+	g.m[key] = call
+	g.mu.Unlock()
+
+	g.doCall(key, call, fn)
+	if call.panic != nil {
+		panic(call.panic)
+	}
+	return call.val, call.err
+}
+
+// doCall 执行 fn，无论是否 panic 都唤醒等待者并移除 key
+func (g *flightGroup) doCall(key string, call *flightCall, fn func() (any, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.panic = &flightPanic{value: r, stack: debug.Stack()}
+		}
+		g.mu.Lock()
+		delete(g.m, key)
+		g.mu.Unlock()
+		call.wg.Done()
+	}()
+	call.val, call.err = fn()
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFlightGroupPanic(t *testing.T) {
+	var g flightGroup
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	panics := make(chan any, 2)
+	call := func(fn func() (any, error)) {
+		defer wg.Done()
+		defer func() { panics <- recover() }()
+		g.Do("k", fn)
+	}
+
+	wg.Add(1)
+	go call(func() (any, error) {
+		close(started)
+		<-release
+		panic("boom")
+	})
+	<-started
+
+	// 等待者加入同一次调用
+	wg.Add(1)
+	go call(func() (any, error) {
+		t.Error("waiter fn should not run")
+		return nil, nil
+	})
+	for {
+		g.mu.Lock()
+		n := len(g.m)
+		g.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("callers not released after panic")
+	}
+
+	close(panics)
+	n := 0
+	for p := range panics {
+		fp, ok := p.(*flightPanic)
+		if !ok || fp.value != "boom" {
+			t.Errorf("recovered %v, want flightPanic(boom)", p)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("got %d panics, want 2", n)
+	}
+
+	g.mu.Lock()
+	_, ok := g.m["k"]
+	g.mu.Unlock()
+	if ok {
+		t.Error("key not removed after panic")
+	}
+
+	// key 移除后可再次调用
+	v, err := g.Do("k", func() (any, error) { return 1, nil })
+	if v != 1 || err != nil {
+		t.Errorf("Do after panic = %v, %v", v, err)
+	}
+}
@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tandy9527/js-util/logger"
+)
+
+const (
+	// 默认失效广播频道
+	defaultInvalidateChannel = "cache:local:invalidate"
+	defaultLocalCacheSize    = 10000
+)
+
+// LocalCache 二级缓存：进程内 LRU + Redis
+// 读取优先命中本地，未命中再读 Redis 并回填；
+// 通过 LocalCache 执行的 Set/Del 会经 pub/sub 广播，所有实例同步淘汰本地条目
+type LocalCache struct {
+	cli     *RedisClient
+	size    int
+	ttl     time.Duration
+	channel string
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+	gen   uint64 // 每次淘汰递增，回填前校验，避免读 Redis 期间收到的失效被旧值覆盖
+
+	sub *Subscriber
+}
+
+type localEntry struct {
+	key      string
+	value    string
+	expireAt time.Time
+}
+
+// NewLocalCache 创建二级缓存并订阅失效广播
+// size: 本地最多缓存条目数，<=0 时默认 10000
+// ttl: 本地条目存活时间，兜底防止广播丢失导致长期脏读
+// channel: 失效广播频道，为空时使用默认频道，同一份数据的所有实例需一致
+func (c *RedisClient) NewLocalCache(size int, ttl time.Duration, channel string) *LocalCache {
+	if size <= 0 {
+		size = defaultLocalCacheSize
+	}
+	if channel == "" {
+		channel = defaultInvalidateChannel
+	}
+	l := &LocalCache{
+		cli:     c,
+		size:    size,
+		ttl:     ttl,
+		channel: channel,
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+	}
+	// 单协程处理，保证失效顺序；CloseRedis / RedisManager.Close 时随 Subscriber 一起关闭
+	l.sub = c.NewSubscriber(1, 0)
+	l.sub.OnSubscription(func(kind, ch string) {
+		if kind == "subscribe" {
+			// (重新)订阅成功，断线期间可能丢失广播，清空本地
+			l.Purge()
+		}
+	})
+	err := l.sub.Subscribe(channel, func(ctx context.Context, _, key string) error {
+		l.evict(key)
+		return nil
+	})
+	if err != nil {
+		// 订阅记录已保留，重连后自动恢复
+		logger.Errorf("[LocalCache] subscribe %s failed: %v", channel, err)
+	}
+	return l
+}
+
+// Get 读取 key，本地未命中时读取 Redis 并回填
+func (l *LocalCache) Get(key string, timeout ...time.Duration) (string, error) {
+	val, gen, ok := l.getLocal(key)
+	if ok {
+		return val, nil
+	}
+	val, err := l.cli.Get(key, timeout...)
+	if err != nil {
+		return "", err
+	}
+	l.setLocal(key, val, gen)
+	return val, nil
+}
+
+// Set 写入 Redis 并广播失效
+func (l *LocalCache) Set(key string, value any, expiration time.Duration, timeout ...time.Duration) error {
+	if err := l.cli.Set(key, value, expiration, timeout...); err != nil {
+		return err
+	}
+	return l.Invalidate(key)
+}
+
+// Del 删除 Redis 中的 key 并广播失效
+func (l *LocalCache) Del(key string, timeout ...time.Duration) error {
+	if err := l.cli.Del(key, timeout...); err != nil {
+		return err
+	}
+	return l.Invalidate(key)
+}
+
+// Invalidate 淘汰本地条目并通知其他实例，用于绕过 LocalCache 直接修改 Redis 的场景
+func (l *LocalCache) Invalidate(key string) error {
+	l.evict(key)
+	_, err := l.cli.Publish(l.channel, key)
+	return err
+}
+
+// Purge 清空本地缓存
+func (l *LocalCache) Purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ll.Init()
+	l.items = make(map[string]*list.Element)
+	l.gen++
+}
+
+// Len 本地条目数
+func (l *LocalCache) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ll.Len()
+}
+
+// Close 取消订阅，可重复调用
+func (l *LocalCache) Close() {
+	l.sub.Close()
+}
+
+// getLocal 读取本地条目，未命中时返回当前失效代数，供回填时校验
+func (l *LocalCache) getLocal(key string) (string, uint64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	el, ok := l.items[key]
+	if !ok {
+		return "", l.gen, false
+	}
+	entry := el.Value.(*localEntry)
+	if l.ttl > 0 && time.Now().After(entry.expireAt) {
+		l.removeElement(el)
+		return "", l.gen, false
+	}
+	l.ll.MoveToFront(el)
+	return entry.value, l.gen, true
+}
+
+// setLocal 回填本地，gen 与当前代数不一致说明期间发生过淘汰，值可能已过期，放弃回填
+func (l *LocalCache) setLocal(key, value string, gen uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if gen != l.gen {
+		return
+	}
+	expireAt := time.Now().Add(l.ttl)
+	if el, ok := l.items[key]; ok {
+		entry := el.Value.(*localEntry)
+		entry.value = value
+		entry.expireAt = expireAt
+		l.ll.MoveToFront(el)
+		return
+	}
+	l.items[key] = l.ll.PushFront(&localEntry{key: key, value: value, expireAt: expireAt})
+	if l.ll.Len() > l.size {
+		l.removeElement(l.ll.Back())
+	}
+}
+
+func (l *LocalCache) evict(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.gen++
+	if el, ok := l.items[key]; ok {
+		l.removeElement(el)
+	}
+}
+
+func (l *LocalCache) removeElement(el *list.Element) {
+	l.ll.Remove(el)
+	delete(l.items, el.Value.(*localEntry).key)
+}
@@ -0,0 +1,208 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/tandy9527/js-util/logger"
+	"github.com/tandy9527/js-util/tools/str_tools"
+)
+
+var (
+	// ErrLockTimeout 等待获取锁超时
+	ErrLockTimeout = errors.New("cache: lock wait timeout")
+	// ErrLockNotHeld 释放未持有（或已过期）的锁
+	ErrLockNotHeld = errors.New("cache: lock not held")
+)
+
+const (
+	defaultLockTTL       = 30 * time.Second
+	defaultLockRetryWait = 50 * time.Millisecond
+)
+
+// 锁存储为 hash：field=owner，value=重入次数
+// KEYS[1]=锁 key  ARGV[1]=ttl(ms)  ARGV[2]=owner
+var lockAcquireScript = RegisterScript("cache.lock_acquire", `
+if redis.call('exists', KEYS[1]) == 0 or redis.call('hexists', KEYS[1], ARGV[2]) == 1 then
+	redis.call('hincrby', KEYS[1], ARGV[2], 1)
+	redis.call('pexpire', KEYS[1], ARGV[1])
+	return 1
+end
+return 0
+`)
+
+// KEYS[1]=锁 key  ARGV[1]=owner  ARGV[2]=ttl(ms)
+// 返回 -1 未持有，0 已完全释放，>0 剩余重入次数
+var lockReleaseScript = RegisterScript("cache.lock_release", `
+if redis.call('hexists', KEYS[1], ARGV[1]) == 0 then
+	return -1
+end
+local n = redis.call('hincrby', KEYS[1], ARGV[1], -1)
+if n > 0 then
+	redis.call('pexpire', KEYS[1], ARGV[2])
+	return n
+end
+redis.call('del', KEYS[1])
+return 0
+`)
+
+// KEYS[1]=锁 key  ARGV[1]=owner  ARGV[2]=ttl(ms)
+var lockRenewScript = RegisterScript("cache.lock_renew", `
+if redis.call('hexists', KEYS[1], ARGV[1]) == 1 then
+	redis.call('pexpire', KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// Lock 基于 Redis 的分布式可重入锁
+// 同一 owner 可重复加锁，需对应次数的 Unlock 才真正释放；
+// 持有期间后台看门狗每 ttl/3 续期一次，进程崩溃后锁在 ttl 后自动过期
+type Lock struct {
+	cli   *RedisClient
+	key   string
+	owner string
+	ttl   time.Duration
+
+	mu   sync.Mutex
+	held int           // 本地持有次数
+	stop chan struct{} // 关闭看门狗
+}
+
+// NewLock 创建分布式锁
+// key: 锁名
+// ttl: 租约时长，<=0 时默认 30s
+// owner: 持有者标识，相同 owner 视为同一持有者（可重入），为空时随机生成
+func (c *RedisClient) NewLock(key string, ttl time.Duration, owner string) *Lock {
+	if ttl <= 0 {
+		ttl = defaultLockTTL
+	}
+	if owner == "" {
+		owner = str_tools.RandLetterStr(20)
+	}
+	return &Lock{
+		cli:   c,
+		key:   key,
+		owner: owner,
+		ttl:   ttl,
+	}
+}
+
+// Key 锁名
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Owner 持有者标识
+func (l *Lock) Owner() string {
+	return l.owner
+}
+
+// TryLock 尝试加锁一次，不等待
+func (l *Lock) TryLock() (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	res, err := lockAcquireScript.Run(l.cli, []string{l.key}, l.ttl.Milliseconds(), l.owner)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.(int64); n != 1 {
+		return false, nil
+	}
+	l.held++
+	if l.stop == nil {
+		l.stop = make(chan struct{})
+		go l.watchdog(l.stop)
+	}
+	return true, nil
+}
+
+// Lock 加锁，最多等待 wait，超时返回 ErrLockTimeout
+// 客户端通过 WithContext 绑定的 ctx 被取消时立即返回 ctx.Err()
+func (l *Lock) Lock(wait time.Duration) error {
+	deadline := time.Now().Add(wait)
+	ctx := l.cli.Context()
+	for {
+		ok, err := l.TryLock()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		remain := time.Until(deadline)
+		if remain <= 0 {
+			return ErrLockTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(min(remain, defaultLockRetryWait)):
+		}
+	}
+}
+
+// Unlock 释放一次锁，重入次数归零时删除锁并停止看门狗
+// 锁已不属于当前 owner（过期被他人获取）时返回 ErrLockNotHeld
+func (l *Lock) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	res, err := lockReleaseScript.Run(l.cli, []string{l.key}, l.owner, l.ttl.Milliseconds())
+	if err != nil {
+		return err
+	}
+	n, _ := res.(int64)
+	if n < 0 {
+		l.stopWatchdog()
+		return ErrLockNotHeld
+	}
+	if l.held > 0 {
+		l.held--
+	}
+	if n == 0 || l.held == 0 {
+		l.stopWatchdog()
+	}
+	return nil
+}
+
+func (l *Lock) stopWatchdog() {
+	l.held = 0
+	if l.stop != nil {
+		close(l.stop)
+		l.stop = nil
+	}
+}
+
+// watchdog 持有期间定时续期，不受调用方 ctx 取消影响
+func (l *Lock) watchdog(stop chan struct{}) {
+	cli := l.cli.WithContext(context.Background())
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			res, err := lockRenewScript.Run(cli, []string{l.key}, l.owner, l.ttl.Milliseconds())
+			if err != nil {
+				logger.Warnf("[Lock] renew %s failed: %v", l.key, err)
+				continue
+			}
+			if n, _ := res.(int64); n != 1 {
+				logger.Errorf("[Lock] %s lost, owner=%s", l.key, l.owner)
+				// 重置本地状态，之后重新加锁会启动新的看门狗
+				l.mu.Lock()
+				if l.stop == stop {
+					l.held = 0
+					l.stop = nil
+				}
+				l.mu.Unlock()
+				return
+			}
+		}
+	}
+}
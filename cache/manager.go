@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tandy9527/js-util/logger"
+)
+
+// ErrClientNotFound 未配置的 Redis 客户端
+var ErrClientNotFound = errors.New("cache: redis client not found")
+
+const (
+	defaultCommandTimeout = 2 * time.Second
+	maxConnectBackoff     = 30 * time.Second
+)
+
+// ManagerOptions RedisManager 可选参数
+type ManagerOptions struct {
+	// Lazy 为 true 时不在初始化时 Ping，首次执行命令时才建立连接
+	Lazy bool
+	// Retries Ping 失败后的重试次数
+	Retries int
+	// Backoff 首次重试间隔，之后每次翻倍，最长 30s，默认 1s
+	Backoff time.Duration
+	// PingTimeout 单次 Ping 超时，默认 2s
+	PingTimeout time.Duration
+	// Timeout 命令默认超时，默认 2s
+	Timeout time.Duration
+}
+
+// RedisManager 一组具名 Redis 客户端
+// 可创建多个互不影响的管理器，LoadRedis/GetDB/CloseRedis 操作的是默认管理器
+type RedisManager struct {
+	mu      sync.RWMutex
+	clients map[string]*RedisClient
+}
+
+// NewRedisManager 按配置创建所有客户端
+// 非 Lazy 模式下任一客户端连接失败（含重试）都会关闭已创建的客户端并返回错误
+func NewRedisManager(cfg *RedisMap, opts ...ManagerOptions) (*RedisManager, error) {
+	var opt ManagerOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Backoff <= 0 {
+		opt.Backoff = time.Second
+	}
+	if opt.PingTimeout <= 0 {
+		opt.PingTimeout = 2 * time.Second
+	}
+	if opt.Timeout <= 0 {
+		opt.Timeout = defaultCommandTimeout
+	}
+
+	m := &RedisManager{clients: make(map[string]*RedisClient)}
+	for name, c := range cfg.Redis {
+		rdb, err := newUniversalClient(c)
+		if err != nil {
+			m.Close()
+			return nil, fmt.Errorf("Redis[%s] Config invalid: %w", name, err)
+		}
+		rdb.AddHook(instrumentHook{client: name})
+		cli := &RedisClient{name: name, rdb: rdb, timeout: opt.Timeout}
+		m.clients[name] = cli
+
+		if opt.Lazy {
+			logger.Infof("Redis[%s] Created (lazy), mode=%s", name, modeName(c.Mode))
+			continue
+		}
+		if err := cli.connect(name, opt); err != nil {
+			m.Close()
+			return nil, fmt.Errorf("Redis[%s] Connection failed: %w", name, err)
+		}
+		// 预加载失败不影响使用，执行时会回退为 EVAL
+		if err := cli.LoadScripts(); err != nil {
+			logger.Warnf("Redis[%s] Preload scripts failed: %v", name, err)
+		}
+		logger.Infof("Redis[%s] Connection successful, mode=%s", name, modeName(c.Mode))
+	}
+	return m, nil
+}
+
+// connect Ping，失败按指数退避重试
+func (c *RedisClient) connect(name string, opt ManagerOptions) error {
+	backoff := opt.Backoff
+	var err error
+	for i := 0; ; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), opt.PingTimeout)
+		err = c.rdb.Ping(ctx).Err()
+		cancel()
+		if err == nil || i >= opt.Retries {
+			return err
+		}
+		logger.Warnf("Redis[%s] Ping failed, retry %d/%d after %v: %v", name, i+1, opt.Retries, backoff, err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+// Get 获取指定客户端，未配置返回 ErrClientNotFound
+func (m *RedisManager) Get(name string) (*RedisClient, error) {
+	if cli := m.GetDB(name); cli != nil {
+		return cli, nil
+	}
+	return nil, ErrClientNotFound
+}
+
+// GetDB 获取指定客户端，未配置返回 nil
+func (m *RedisManager) GetDB(name string) *RedisClient {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.clients[name]
+}
+
+// Names 所有客户端名称
+func (m *RedisManager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.clients))
+	for name := range m.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close 关闭所有客户端及其订阅
+func (m *RedisManager) Close() {
+	m.mu.Lock()
+	clients := m.clients
+	m.clients = make(map[string]*RedisClient)
+	m.mu.Unlock()
+
+	for name, cli := range clients {
+		closeSubscribers(cli)
+		if err := cli.rdb.Close(); err != nil {
+			logger.Errorf("Redis[%s] Close failed: %v", name, err)
+		} else {
+			logger.Infof("Redis[%s] Closed", name)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets 命令耗时直方图默认分桶（秒）
+var DefaultBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// DefaultMetrics 默认指标收集器，需通过 AddHook(DefaultMetrics) 启用
+var DefaultMetrics = NewMetrics()
+
+// Metrics 进程内命令指标，按 客户端 + 命令 统计调用次数、错误次数、超时次数和耗时直方图
+// 阻塞命令的耗时包含等待时间，不计入直方图
+// 可通过 WritePrometheus 输出 Prometheus 文本格式
+type Metrics struct {
+	buckets []float64
+
+	mu    sync.Mutex
+	stats map[metricKey]*commandStats
+}
+
+type metricKey struct {
+	client string
+	cmd    string
+}
+
+type commandStats struct {
+	calls    uint64
+	errors   uint64
+	timeouts uint64
+	counts   []uint64 // 每个分桶的计数（非累计），最后一个为 +Inf
+	sum      float64
+	observed uint64
+}
+
+// NewMetrics 创建指标收集器，buckets 为空时使用 DefaultBuckets
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	return &Metrics{buckets: b, stats: make(map[metricKey]*commandStats)}
+}
+
+// AfterCommand 实现 Hook
+func (m *Metrics) AfterCommand(ctx context.Context, info CommandInfo) {
+	k := metricKey{client: info.Client, cmd: info.Name}
+	sec := info.Duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.stats[k]
+	if s == nil {
+		s = &commandStats{counts: make([]uint64, len(m.buckets)+1)}
+		m.stats[k] = s
+	}
+	s.calls++
+	if info.Err != nil {
+		s.errors++
+		if IsTimeout(info.Err) {
+			s.timeouts++
+		}
+	}
+	if info.Blocking {
+		return
+	}
+	i := sort.SearchFloat64s(m.buckets, sec)
+	s.counts[i]++
+	s.sum += sec
+	s.observed++
+}
+
+// CommandStat 单个 客户端 + 命令 的统计快照
+type CommandStat struct {
+	Client   string
+	Command  string
+	Calls    uint64
+	Errors   uint64
+	Timeouts uint64
+	Avg      time.Duration // 平均耗时，不含阻塞命令
+}
+
+// Snapshot 当前统计快照，按客户端、命令排序
+func (m *Metrics) Snapshot() []CommandStat {
+	m.mu.Lock()
+	out := make([]CommandStat, 0, len(m.stats))
+	for k, s := range m.stats {
+		st := CommandStat{Client: k.client, Command: k.cmd, Calls: s.calls, Errors: s.errors, Timeouts: s.timeouts}
+		if s.observed > 0 {
+			st.Avg = time.Duration(s.sum / float64(s.observed) * float64(time.Second))
+		}
+		out = append(out, st)
+	}
+	m.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Client != out[j].Client {
+			return out[i].Client < out[j].Client
+		}
+		return out[i].Command < out[j].Command
+	})
+	return out
+}
+
+// Reset 清空统计
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	m.stats = make(map[metricKey]*commandStats)
+	m.mu.Unlock()
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出指标：
+//
+//	redis_commands_total{client,cmd}
+//	redis_command_errors_total{client,cmd}
+//	redis_command_timeouts_total{client,cmd}
+//	redis_command_duration_seconds{client,cmd}（histogram）
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	type entry struct {
+		k metricKey
+		s commandStats
+	}
+	m.mu.Lock()
+	entries := make([]entry, 0, len(m.stats))
+	for k, s := range m.stats {
+		cp := *s
+		cp.counts = append([]uint64(nil), s.counts...)
+		entries = append(entries, entry{k, cp})
+	}
+	m.mu.Unlock()
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].k.client != entries[j].k.client {
+			return entries[i].k.client < entries[j].k.client
+		}
+		return entries[i].k.cmd < entries[j].k.cmd
+	})
+
+	bw := bufio.NewWriter(w)
+	counter := func(name, help string, val func(s *commandStats) uint64) {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for i := range entries {
+			e := &entries[i]
+			fmt.Fprintf(bw, "%s{%s} %d\n", name, labels(e.k), val(&e.s))
+		}
+	}
+	counter("redis_commands_total", "Total number of redis commands.", func(s *commandStats) uint64 { return s.calls })
+	counter("redis_command_errors_total", "Total number of failed redis commands.", func(s *commandStats) uint64 { return s.errors })
+	counter("redis_command_timeouts_total", "Total number of timed out redis commands.", func(s *commandStats) uint64 { return s.timeouts })
+
+	const hist = "redis_command_duration_seconds"
+	fmt.Fprintf(bw, "# HELP %s Redis command latency, blocking commands excluded.\n# TYPE %s histogram\n", hist, hist)
+	for i := range entries {
+		e := &entries[i]
+		l := labels(e.k)
+		var cum uint64
+		for j, b := range m.buckets {
+			cum += e.s.counts[j]
+			fmt.Fprintf(bw, "%s_bucket{%s,le=\"%s\"} %d\n", hist, l, strconv.FormatFloat(b, 'g', -1, 64), cum)
+		}
+		cum += e.s.counts[len(m.buckets)]
+		fmt.Fprintf(bw, "%s_bucket{%s,le=\"+Inf\"} %d\n", hist, l, cum)
+		fmt.Fprintf(bw, "%s_sum{%s} %s\n", hist, l, strconv.FormatFloat(e.s.sum, 'g', -1, 64))
+		fmt.Fprintf(bw, "%s_count{%s} %d\n", hist, l, e.s.observed)
+	}
+	return bw.Flush()
+}
+
+// Handler 输出 Prometheus 文本格式的 HTTP 接口，可挂到 /metrics
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = m.WritePrometheus(w)
+	})
+}
+
+func labels(k metricKey) string {
+	return "client=" + strconv.Quote(k.client) + ",cmd=" + strconv.Quote(k.cmd)
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrTxRetryExhausted Watch 事务重试次数用尽
+var ErrTxRetryExhausted = errors.New("cache: watch transaction retries exhausted")
+
+// 默认 Watch 重试次数
+const defaultWatchRetries = 3
+
+// Pipelined 管道批量执行，多条命令一次往返，非原子
+// fn 的 ctx 已带超时，对 pipe 调用的每个命令都返回带类型的 Cmd（*redis.IntCmd、*redis.StringCmd ...），
+// 执行完成后可直接读取其 Val()/Err()
+// 返回值 cmds 为每条命令的结果，err 为第一条失败命令的错误（不存在也算失败，返回 ErrNotFound）
+//
+//	var n *redis.IntCmd
+//	_, err := cli.Pipelined(func(ctx context.Context, pipe redis.Pipeliner) error {
+//		pipe.HSet(ctx, "user:1", "name", "tom")
+//		n = pipe.Incr(ctx, "counter")
+//		pipe.Expire(ctx, "user:1", time.Hour)
+//		return nil
+//	})
+func (c *RedisClient) Pipelined(fn func(ctx context.Context, pipe redis.Pipeliner) error, timeout ...time.Duration) ([]redis.Cmder, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			return fn(ctx, pipe)
+		})
+	}, timeout...)
+	cmds, _ := res.([]redis.Cmder)
+	return cmds, err
+}
+
+// TxPipelined 事务执行，命令包裹在 MULTI/EXEC 中原子提交
+// cluster 模式下所有 key 必须落在同一个 slot（可使用 {hash tag}）
+func (c *RedisClient) TxPipelined(fn func(ctx context.Context, pipe redis.Pipeliner) error, timeout ...time.Duration) ([]redis.Cmder, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return fn(ctx, pipe)
+		})
+	}, timeout...)
+	cmds, _ := res.([]redis.Cmder)
+	return cmds, err
+}
+
+// Watch 基于 WATCH 的乐观锁事务
+// fn 中先用 tx 读取数据，再通过 tx.TxPipelined 写入，ctx 已带超时；
+// 若 keys 在提交前被其他客户端修改，会自动重试，最多 maxRetries 次（<=0 时使用默认 3 次）
+// 重试用尽返回 ErrTxRetryExhausted
+// 超时作用于整个重试过程
+//
+//	err := cli.Watch(func(ctx context.Context, tx *redis.Tx) error {
+//		n, err := tx.Get(ctx, "stock").Int()
+//		if err != nil && err != redis.Nil {
+//			return err
+//		}
+//		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+//			pipe.Set(ctx, "stock", n-1, 0)
+//			return nil
+//		})
+//		return err
+//	}, 5, []string{"stock"})
+func (c *RedisClient) Watch(fn func(ctx context.Context, tx *redis.Tx) error, maxRetries int, keys []string, timeout ...time.Duration) error {
+	if maxRetries <= 0 {
+		maxRetries = defaultWatchRetries
+	}
+	_, err := c.do(func(ctx context.Context) (any, error) {
+		for i := 0; i < maxRetries; i++ {
+			err := c.rdb.Watch(ctx, func(tx *redis.Tx) error {
+				return fn(ctx, tx)
+			}, keys...)
+			if err != redis.TxFailedErr {
+				return nil, err
+			}
+			// 冲突，短暂退避后重试
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(i+1) * 10 * time.Millisecond):
+			}
+		}
+		return nil, ErrTxRetryExhausted
+	}, timeout...)
+	return err
+}
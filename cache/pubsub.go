@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tandy9527/js-util/logger"
+)
+
+// PubSubHandler 订阅消息处理函数
+type PubSubHandler func(ctx context.Context, channel, payload string) error
+
+const (
+	defaultSubscriberWorkers = 4
+	defaultSubscriberQueue   = 1024
+)
+
+var (
+	subscribersMu sync.Mutex
+	subscribers   = make(map[*Subscriber]struct{})
+)
+
+// Publish 发布消息，返回收到消息的订阅者数量
+func (c *RedisClient) Publish(channel string, message any, timeout ...time.Duration) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.Publish(ctx, channel, message).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// Subscriber 订阅管理器
+// 一个 Subscriber 共用一条订阅连接，支持频道和模式订阅，
+// 断线后由 go-redis 自动重连并恢复全部订阅；
+// 消息按频道/模式分发给对应 handler，在固定大小的协程池中执行（不保证同频道消息顺序）
+// CloseRedis / RedisManager.Close 时自动关闭
+type Subscriber struct {
+	cli    *RedisClient
+	pubsub *redis.PubSub
+
+	mu       sync.RWMutex
+	channels map[string]PubSubHandler
+	patterns map[string]PubSubHandler
+	onSub    func(kind, channel string)
+
+	jobs   chan *redis.Message
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// NewSubscriber 创建订阅管理器
+// workers: 处理协程数，<=0 时默认 4
+// queueSize: 待处理消息队列长度，<=0 时默认 1024，队列满时阻塞接收
+func (c *RedisClient) NewSubscriber(workers, queueSize int) *Subscriber {
+	if workers <= 0 {
+		workers = defaultSubscriberWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = defaultSubscriberQueue
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Subscriber{
+		cli:      c,
+		pubsub:   c.rdb.Subscribe(ctx),
+		channels: make(map[string]PubSubHandler),
+		patterns: make(map[string]PubSubHandler),
+		jobs:     make(chan *redis.Message, queueSize),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	for i := 0; i < workers; i++ {
+		s.wg.Add(1)
+		go s.work()
+	}
+	s.wg.Add(1)
+	go s.receive()
+
+	subscribersMu.Lock()
+	subscribers[s] = struct{}{}
+	subscribersMu.Unlock()
+	return s
+}
+
+// Subscribe 订阅频道
+func (s *Subscriber) Subscribe(channel string, handler PubSubHandler) error {
+	s.mu.Lock()
+	s.channels[channel] = handler
+	s.mu.Unlock()
+	_, err := s.cli.do(func(ctx context.Context) (any, error) {
+		return nil, s.pubsub.Subscribe(ctx, channel)
+	})
+	return err
+}
+
+// PSubscribe 按模式订阅，如 "kick:*"
+func (s *Subscriber) PSubscribe(pattern string, handler PubSubHandler) error {
+	s.mu.Lock()
+	s.patterns[pattern] = handler
+	s.mu.Unlock()
+	_, err := s.cli.do(func(ctx context.Context) (any, error) {
+		return nil, s.pubsub.PSubscribe(ctx, pattern)
+	})
+	return err
+}
+
+// OnSubscription 设置订阅状态回调，(重新)订阅或取消订阅成功时在接收协程中调用
+// kind 为 subscribe / psubscribe / unsubscribe / punsubscribe；
+// 断线重连期间的消息会丢失，可在重新订阅时做补偿（如清空本地缓存）
+func (s *Subscriber) OnSubscription(fn func(kind, channel string)) {
+	s.mu.Lock()
+	s.onSub = fn
+	s.mu.Unlock()
+}
+
+// Unsubscribe 取消频道订阅
+func (s *Subscriber) Unsubscribe(channels ...string) error {
+	s.mu.Lock()
+	for _, ch := range channels {
+		delete(s.channels, ch)
+	}
+	s.mu.Unlock()
+	_, err := s.cli.do(func(ctx context.Context) (any, error) {
+		return nil, s.pubsub.Unsubscribe(ctx, channels...)
+	})
+	return err
+}
+
+// PUnsubscribe 取消模式订阅
+func (s *Subscriber) PUnsubscribe(patterns ...string) error {
+	s.mu.Lock()
+	for _, p := range patterns {
+		delete(s.patterns, p)
+	}
+	s.mu.Unlock()
+	_, err := s.cli.do(func(ctx context.Context) (any, error) {
+		return nil, s.pubsub.PUnsubscribe(ctx, patterns...)
+	})
+	return err
+}
+
+// Close 关闭订阅，等待正在处理的消息完成
+func (s *Subscriber) Close() {
+	s.once.Do(func() {
+		subscribersMu.Lock()
+		delete(subscribers, s)
+		subscribersMu.Unlock()
+
+		s.cancel()
+		if err := s.pubsub.Close(); err != nil {
+			logger.Errorf("[PubSub] close failed: %v", err)
+		}
+		s.wg.Wait()
+	})
+}
+
+func (s *Subscriber) receive() {
+	defer s.wg.Done()
+	ch := s.pubsub.ChannelWithSubscriptions()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			switch m := msg.(type) {
+			case *redis.Subscription:
+				logger.Infof("[PubSub] %s %s", m.Kind, m.Channel)
+				s.mu.RLock()
+				fn := s.onSub
+				s.mu.RUnlock()
+				if fn != nil {
+					fn(m.Kind, m.Channel)
+				}
+			case *redis.Message:
+				select {
+				case s.jobs <- m:
+				case <-s.ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *Subscriber) work() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case m := <-s.jobs:
+			s.mu.RLock()
+			var handler PubSubHandler
+			if m.Pattern != "" {
+				handler = s.patterns[m.Pattern]
+			} else {
+				handler = s.channels[m.Channel]
+			}
+			s.mu.RUnlock()
+			if handler == nil {
+				continue
+			}
+			if err := handler(s.ctx, m.Channel, m.Payload); err != nil {
+				logger.Errorf("[PubSub] handler error, channel=%s: %v", m.Channel, err)
+			}
+		}
+	}
+}
+
+// closeSubscribers 关闭 cli 上创建的所有订阅管理器
+func closeSubscribers(cli *RedisClient) {
+	subscribersMu.Lock()
+	list := make([]*Subscriber, 0, len(subscribers))
+	for s := range subscribers {
+		if s.cli.rdb == cli.rdb {
+			list = append(list, s)
+		}
+	}
+	subscribersMu.Unlock()
+	for _, s := range list {
+		s.Close()
+	}
+}
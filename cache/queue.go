@@ -0,0 +1,292 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tandy9527/js-util/logger"
+)
+
+// QueueHandler 队列消息处理函数，返回 error 视为失败并重试
+type QueueHandler func(ctx context.Context, item string) error
+
+const (
+	defaultQueueVisibility = 30 * time.Second
+	defaultQueueMaxRetry   = 3
+	queuePopBlock          = time.Second
+)
+
+// KEYS[1]=processing KEYS[2]=inflight KEYS[3]=retries  ARGV[1]=item
+var queueAckScript = RegisterScript("cache.queue_ack", `
+redis.call('LREM', KEYS[1], 1, ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('HDEL', KEYS[3], ARGV[1])
+return 1
+`)
+
+// KEYS[1]=processing KEYS[2]=inflight KEYS[3]=retries KEYS[4]=queue KEYS[5]=dead
+// ARGV[1]=item ARGV[2]=maxRetry
+// 返回 1 进入死信，0 重新入队，-1 不在处理中
+var queueNackScript = RegisterScript("cache.queue_nack", `
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 0 then
+	return -1
+end
+redis.call('ZREM', KEYS[2], ARGV[1])
+local n = redis.call('HINCRBY', KEYS[3], ARGV[1], 1)
+if n > tonumber(ARGV[2]) then
+	redis.call('HDEL', KEYS[3], ARGV[1])
+	redis.call('LPUSH', KEYS[5], ARGV[1])
+	return 1
+end
+redis.call('LPUSH', KEYS[4], ARGV[1])
+return 0
+`)
+
+// KEYS[1]=processing KEYS[2]=inflight KEYS[3]=queue  ARGV[1]=item
+// 放回队列右侧（下一个被取出），不计重试次数
+var queueRequeueScript = RegisterScript("cache.queue_requeue", `
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('RPUSH', KEYS[3], ARGV[1])
+return 1
+`)
+
+// 扫描 processing 列表，可见性超时的重新入队（超过重试次数进入死信）
+// 没有截止时间的（取出后未来得及登记即崩溃）补登记一个截止时间
+// KEYS 同 queueNackLua  ARGV[1]=now(ms) ARGV[2]=visibility(ms) ARGV[3]=maxRetry
+var queueRecoverScript = RegisterScript("cache.queue_recover", `
+local now = tonumber(ARGV[1])
+local items = redis.call('LRANGE', KEYS[1], 0, -1)
+local moved = 0
+for _, item in ipairs(items) do
+	local deadline = redis.call('ZSCORE', KEYS[2], item)
+	if not deadline then
+		redis.call('ZADD', KEYS[2], now + tonumber(ARGV[2]), item)
+	elseif tonumber(deadline) < now then
+		redis.call('LREM', KEYS[1], 1, item)
+		redis.call('ZREM', KEYS[2], item)
+		local n = redis.call('HINCRBY', KEYS[3], item, 1)
+		if n > tonumber(ARGV[3]) then
+			redis.call('HDEL', KEYS[3], item)
+			redis.call('LPUSH', KEYS[5], item)
+		else
+			redis.call('RPUSH', KEYS[4], item)
+		end
+		moved = moved + 1
+	end
+end
+return moved
+`)
+
+// Queue 基于 list 的可靠队列
+// 消费时通过 BRPopLPush 原子移入 processing 列表，处理成功 Ack 删除，失败 Nack 重新入队；
+// 超过可见性超时仍未确认的消息会被重新投递，重试超过 maxRetry 次进入死信列表
+// 相同内容的消息会互相影响确认状态，消息体应带唯一 id
+// cluster 模式下 name 需使用 {hash tag}，保证相关 key 在同一 slot
+type Queue struct {
+	cli        *RedisClient
+	name       string
+	processing string
+	inflight   string // zset：item -> 可见性截止时间 ms
+	retries    string // hash：item -> 失败次数
+	dead       string
+	visibility time.Duration
+	maxRetry   int
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewQueue 创建队列
+// visibility: 消息取出后多久未确认视为处理失败，<=0 时默认 30s
+// maxRetry: 最大重试次数，<=0 时默认 3
+func (c *RedisClient) NewQueue(name string, visibility time.Duration, maxRetry int) *Queue {
+	if visibility <= 0 {
+		visibility = defaultQueueVisibility
+	}
+	if maxRetry <= 0 {
+		maxRetry = defaultQueueMaxRetry
+	}
+	return &Queue{
+		cli:        c,
+		name:       name,
+		processing: name + ":processing",
+		inflight:   name + ":inflight",
+		retries:    name + ":retries",
+		dead:       name + ":dead",
+		visibility: visibility,
+		maxRetry:   maxRetry,
+	}
+}
+
+// DeadLetterKey 死信列表 key
+func (q *Queue) DeadLetterKey() string {
+	return q.dead
+}
+
+// Enqueue 入队
+func (q *Queue) Enqueue(items ...any) error {
+	_, err := q.cli.LPush(q.name, items...)
+	return err
+}
+
+// Len 待处理消息数
+func (q *Queue) Len() (int64, error) {
+	res, err := q.cli.do(func(ctx context.Context) (any, error) {
+		return q.cli.rdb.LLen(ctx, q.name).Result()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// Dequeue 阻塞取出一条消息并移入 processing，最多等待 block，无消息返回 ErrNotFound
+func (q *Queue) Dequeue(block time.Duration) (string, error) {
+	secs := int(block / time.Second)
+	if secs <= 0 {
+		secs = 1
+	}
+	item, err := q.cli.BRPopLPush(q.name, q.processing, secs)
+	if err != nil {
+		return "", err
+	}
+	deadline := float64(time.Now().Add(q.visibility).UnixMilli())
+	if _, err := q.cli.ZAdd(q.inflight, redis.Z{Score: deadline, Member: item}); err != nil {
+		// 登记失败由 Recover 补登记
+		logger.Warnf("[Queue] %s mark inflight failed: %v", q.name, err)
+	}
+	return item, nil
+}
+
+// Ack 确认处理成功
+func (q *Queue) Ack(item string) error {
+	_, err := queueAckScript.Run(q.cli, []string{q.processing, q.inflight, q.retries}, item)
+	return err
+}
+
+// Nack 处理失败，重新入队；超过最大重试次数进入死信，dead 返回 true
+func (q *Queue) Nack(item string) (dead bool, err error) {
+	res, err := queueNackScript.Run(q.cli, q.keys(), item, q.maxRetry)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.(int64)
+	return n == 1, nil
+}
+
+// requeue 停止消费时放回未完成的消息，不计重试次数
+func (q *Queue) requeue(item string) error {
+	_, err := queueRequeueScript.Run(q.cli, []string{q.processing, q.inflight, q.name}, item)
+	return err
+}
+
+// Recover 重新投递可见性超时的消息，返回处理条数
+func (q *Queue) Recover() (int64, error) {
+	res, err := queueRecoverScript.Run(q.cli, q.keys(),
+		time.Now().UnixMilli(), q.visibility.Milliseconds(), q.maxRetry)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.(int64)
+	return n, nil
+}
+
+func (q *Queue) keys() []string {
+	return []string{q.processing, q.inflight, q.retries, q.name, q.dead}
+}
+
+// Start 启动 workers 个消费协程和一个超时恢复协程
+func (q *Queue) Start(handler QueueHandler, workers int) {
+	if workers <= 0 {
+		workers = 1
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.work(ctx, handler)
+	}
+	q.wg.Add(1)
+	go q.recoverLoop(ctx)
+	logger.Infof("[Queue] %s started, workers=%d", q.name, workers)
+}
+
+// Stop 停止消费，等待正在处理的消息完成
+// 处理函数的 ctx 随之取消，此时返回失败的消息放回队列，不计重试次数
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	cancel := q.cancel
+	q.cancel = nil
+	q.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	q.wg.Wait()
+	logger.Infof("[Queue] %s stopped", q.name)
+}
+
+func (q *Queue) work(ctx context.Context, handler QueueHandler) {
+	defer q.wg.Done()
+	for ctx.Err() == nil {
+		item, err := q.Dequeue(queuePopBlock)
+		if err != nil {
+			if !IsNotFound(err) {
+				logger.Errorf("[Queue] %s dequeue error: %v", q.name, err)
+				sleepCtx(ctx, time.Second)
+			}
+			continue
+		}
+
+		if err := handler(ctx, item); err != nil {
+			if ctx.Err() != nil {
+				// 停止导致的失败不是消息本身的问题
+				if err := q.requeue(item); err != nil {
+					logger.Errorf("[Queue] %s requeue error: %v", q.name, err)
+				}
+				return
+			}
+			dead, nackErr := q.Nack(item)
+			if nackErr != nil {
+				logger.Errorf("[Queue] %s nack error: %v", q.name, nackErr)
+			} else if dead {
+				logger.Warnf("[Queue] %s item moved to dead letter: %s, err=%v", q.name, item, err)
+			}
+			continue
+		}
+		if err := q.Ack(item); err != nil {
+			logger.Errorf("[Queue] %s ack error: %v", q.name, err)
+		}
+	}
+}
+
+func (q *Queue) recoverLoop(ctx context.Context) {
+	defer q.wg.Done()
+	ticker := time.NewTicker(q.visibility / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := q.Recover()
+			if err != nil {
+				logger.Errorf("[Queue] %s recover error: %v", q.name, err)
+			} else if n > 0 {
+				logger.Warnf("[Queue] %s recovered %d stuck items", q.name, n)
+			}
+		}
+	}
+}
@@ -4,69 +4,151 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/tandy9527/js-util/logger"
-
 	"github.com/redis/go-redis/v9"
 )
 
 type RedisClient struct {
-	rdb     *redis.Client
+	name    string // 配置中的客户端名
+	rdb     redis.UniversalClient
 	timeout time.Duration
+	ctx     context.Context // 调用方传入的上下文，为 nil 时使用 context.Background()
+	codec   Codec           // GetAs/SetAs 使用的编解码，为 nil 时使用 JSONCodec
 }
 
 var (
-	redisMap = make(map[string]*RedisClient)
-	once     sync.Once
+	defaultManager atomic.Pointer[RedisManager]
+	once           sync.Once
 )
 
-// LoadRedis 初始化多个 Redis 客户端
-func LoadRedis(path string) {
+// LoadRedis 初始化多个 Redis 客户端，失败 panic
+// 等价于 InitRedis 的默认管理器，只初始化一次
+func LoadRedis(path string, opts ...ManagerOptions) {
 	cfg := LoadRedisConf(path)
 	once.Do(func() {
-		// 配置的多个DB
-		for name, c := range cfg.Redis {
-			rdb := redis.NewClient(&redis.Options{
-				Addr:         c.Addr,
-				Password:     c.Password,
-				DB:           c.DB,
-				PoolSize:     c.PoolSize,
-				MinIdleConns: c.MinIdleConns,
-				PoolTimeout:  time.Duration(c.PoolTimeout) * time.Second,
-			})
-
-			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-			defer cancel()
-			if err := rdb.Ping(ctx).Err(); err != nil {
-				panic(fmt.Sprintf("Redis[%s] Connection failed: %v", name, err))
-			}
-
-			redisMap[name] = &RedisClient{
-				rdb:     rdb,
-				timeout: 2 * time.Second,
-			}
-			logger.Infof("Redis[%s] Connection successful", name)
+		if err := InitRedis(cfg, opts...); err != nil {
+			panic(err.Error())
 		}
 	})
 }
 
+// InitRedis 按配置创建默认管理器，替换并关闭旧的默认管理器，可重复调用
+func InitRedis(cfg *RedisMap, opts ...ManagerOptions) error {
+	m, err := NewRedisManager(cfg, opts...)
+	if err != nil {
+		return err
+	}
+	if old := defaultManager.Swap(m); old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// DefaultManager 默认管理器，未初始化时为 nil
+func DefaultManager() *RedisManager {
+	return defaultManager.Load()
+}
+
+// newUniversalClient 根据 Mode 创建单机 / 哨兵 / 集群客户端
+func newUniversalClient(c RedisConf) (redis.UniversalClient, error) {
+	poolTimeout := time.Duration(c.PoolTimeout) * time.Second
+	switch c.Mode {
+	case "", ModeStandalone:
+		return redis.NewClient(&redis.Options{
+			Addr:         c.Addr,
+			Password:     c.Password,
+			DB:           c.DB,
+			PoolSize:     c.PoolSize,
+			MinIdleConns: c.MinIdleConns,
+			PoolTimeout:  poolTimeout,
+		}), nil
+	case ModeSentinel:
+		if c.MasterName == "" || len(c.SentinelAddrs) == 0 {
+			return nil, fmt.Errorf("sentinel mode requires master_name and sentinel_addrs")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       c.MasterName,
+			SentinelAddrs:    c.SentinelAddrs,
+			SentinelPassword: c.SentinelPassword,
+			Password:         c.Password,
+			DB:               c.DB,
+			PoolSize:         c.PoolSize,
+			MinIdleConns:     c.MinIdleConns,
+			PoolTimeout:      poolTimeout,
+		}), nil
+	case ModeCluster:
+		addrs := c.ClusterAddrs
+		if len(addrs) == 0 && c.Addr != "" {
+			addrs = []string{c.Addr}
+		}
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("cluster mode requires cluster_addrs")
+		}
+		if c.DB != 0 {
+			return nil, fmt.Errorf("cluster mode only supports db 0, got %d", c.DB)
+		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        addrs,
+			Password:     c.Password,
+			PoolSize:     c.PoolSize,
+			MinIdleConns: c.MinIdleConns,
+			PoolTimeout:  poolTimeout,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown redis mode %q", c.Mode)
+	}
+}
+
+func modeName(mode string) string {
+	if mode == "" {
+		return ModeStandalone
+	}
+	return mode
+}
+
 // CloseRedis 关闭所有 Redis
 func CloseRedis() {
-	for name, cli := range redisMap {
-		if cli != nil {
-			if err := cli.rdb.Close(); err != nil {
-				logger.Errorf("Redis[%s] Close failed: %v", name, err)
-			} else {
-				logger.Infof("Redis[%s] Closed", name)
-			}
-		}
+	if m := defaultManager.Load(); m != nil {
+		m.Close()
 	}
 }
 
 // GetDB 获取指定 Redis 客户端
 func GetDB(name string) *RedisClient {
-	return redisMap[name]
+	m := defaultManager.Load()
+	if m == nil {
+		return nil
+	}
+	return m.GetDB(name)
+}
+
+// WithContext 返回绑定了 ctx 的客户端视图，共享底层连接池
+// 之后通过该视图执行的所有命令都会继承 ctx 的截止时间和取消信号，
+// 超时参数仍然生效，取两者中较早的一个
+//
+//	val, err := cache.GetDB(cache.DB0).WithContext(ctx).Get("key")
+func (c *RedisClient) WithContext(ctx context.Context) *RedisClient {
+	if ctx == nil {
+		panic("cache: WithContext nil context")
+	}
+	cc := *c
+	cc.ctx = ctx
+	return &cc
+}
+
+// Name 客户端名，即配置中的 key
+func (c *RedisClient) Name() string {
+	return c.name
+}
+
+// Context 返回客户端绑定的上下文，未绑定时返回 context.Background()
+func (c *RedisClient) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
 }
 
 // 内部统一执行函数
@@ -75,12 +157,10 @@ func (c *RedisClient) do(fn func(ctx context.Context) (any, error), timeout ...t
 	if len(timeout) > 0 {
 		t = timeout[0]
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), t)
+	ctx, cancel := context.WithTimeout(c.Context(), t)
 	defer cancel()
-	//start := time.Now()
 	res, err := fn(ctx)
-	//log.Printf("[Redis] cost=%v err=%v", time.Since(start), err)
-	return res, err
+	return res, wrapErr(err)
 }
 
 func (c *RedisClient) Expire(key string, expiration time.Duration, timeout ...time.Duration) error {
@@ -133,21 +213,167 @@ func (c *RedisClient) Incr(key string, timeout ...time.Duration) (int64, error)
 	res, err := c.do(func(ctx context.Context) (any, error) {
 		return c.rdb.Incr(ctx, key).Result()
 	}, timeout...)
-	return res.(int64), err
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
 }
 
 func (c *RedisClient) Decr(key string, timeout ...time.Duration) (int64, error) {
 	res, err := c.do(func(ctx context.Context) (any, error) {
 		return c.rdb.Decr(ctx, key).Result()
 	}, timeout...)
-	return res.(int64), err
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
 }
 
 func (c *RedisClient) DecrBy(key string, decrement int64, timeout ...time.Duration) (int64, error) {
 	res, err := c.do(func(ctx context.Context) (any, error) {
 		return c.rdb.DecrBy(ctx, key, decrement).Result()
 	}, timeout...)
-	return res.(int64), err
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+func (c *RedisClient) IncrBy(key string, increment int64, timeout ...time.Duration) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.IncrBy(ctx, key, increment).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+func (c *RedisClient) IncrByFloat(key string, increment float64, timeout ...time.Duration) (float64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.IncrByFloat(ctx, key, increment).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(float64), nil
+}
+
+// SetNX key 不存在时才设置，返回是否设置成功
+func (c *RedisClient) SetNX(key string, value any, expiration time.Duration, timeout ...time.Duration) (bool, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.SetNX(ctx, key, value, expiration).Result()
+	}, timeout...)
+	if err != nil {
+		return false, err
+	}
+	return res.(bool), nil
+}
+
+// GetSet 设置新值并返回旧值，key 不存在返回 ErrNotFound（新值仍会写入）
+func (c *RedisClient) GetSet(key string, value any, timeout ...time.Duration) (string, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.GetSet(ctx, key, value).Result()
+	}, timeout...)
+	if err != nil {
+		return "", err
+	}
+	return res.(string), nil
+}
+
+// GetDel 获取并删除
+func (c *RedisClient) GetDel(key string, timeout ...time.Duration) (string, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.GetDel(ctx, key).Result()
+	}, timeout...)
+	if err != nil {
+		return "", err
+	}
+	return res.(string), nil
+}
+
+// MGet 批量获取，返回值与 keys 一一对应，不存在的 key 对应 nil
+func (c *RedisClient) MGet(keys []string, timeout ...time.Duration) ([]any, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.MGet(ctx, keys...).Result()
+	}, timeout...)
+	if err != nil {
+		return nil, err
+	}
+	return res.([]any), nil
+}
+
+// MSet 批量设置
+func (c *RedisClient) MSet(values map[string]any, timeout ...time.Duration) error {
+	_, err := c.do(func(ctx context.Context) (any, error) {
+		return nil, c.rdb.MSet(ctx, values).Err()
+	}, timeout...)
+	return err
+}
+
+// StrLen 字符串长度
+func (c *RedisClient) StrLen(key string, timeout ...time.Duration) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.StrLen(ctx, key).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// Append 追加字符串，返回追加后的长度
+func (c *RedisClient) Append(key, value string, timeout ...time.Duration) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.Append(ctx, key, value).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// ---------------- 过期时间 ----------------
+
+// ExpireAt 设置过期时间点
+func (c *RedisClient) ExpireAt(key string, tm time.Time, timeout ...time.Duration) error {
+	_, err := c.do(func(ctx context.Context) (any, error) {
+		return nil, c.rdb.ExpireAt(ctx, key, tm).Err()
+	}, timeout...)
+	return err
+}
+
+// TTL 剩余存活时间（秒级精度），key 不存在返回 -2，未设置过期返回 -1（原始值，与 go-redis 一致）
+func (c *RedisClient) TTL(key string, timeout ...time.Duration) (time.Duration, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.TTL(ctx, key).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(time.Duration), nil
+}
+
+// PTTL 剩余存活时间（毫秒级精度），返回值约定同 TTL
+func (c *RedisClient) PTTL(key string, timeout ...time.Duration) (time.Duration, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.PTTL(ctx, key).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(time.Duration), nil
+}
+
+// Persist 移除过期时间，返回是否移除成功
+func (c *RedisClient) Persist(key string, timeout ...time.Duration) (bool, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.Persist(ctx, key).Result()
+	}, timeout...)
+	if err != nil {
+		return false, err
+	}
+	return res.(bool), nil
 }
 
 // ---------------- Hash 操作 ----------------
@@ -199,6 +425,108 @@ func (c *RedisClient) HGetAll(hashKey string) (map[string]string, error) {
 	return res.(map[string]string), nil
 }
 
+// HSetNX 字段不存在时才设置，返回是否设置成功
+func (c *RedisClient) HSetNX(hashKey string, field string, value any, timeout ...time.Duration) (bool, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.HSetNX(ctx, hashKey, field, value).Result()
+	}, timeout...)
+	if err != nil {
+		return false, err
+	}
+	return res.(bool), nil
+}
+
+// HMGet 批量获取字段，返回值与 fields 一一对应，不存在的字段对应 nil
+func (c *RedisClient) HMGet(hashKey string, fields []string, timeout ...time.Duration) ([]any, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.HMGet(ctx, hashKey, fields...).Result()
+	}, timeout...)
+	if err != nil {
+		return nil, err
+	}
+	return res.([]any), nil
+}
+
+// HIncrBy 字段增加整数值
+func (c *RedisClient) HIncrBy(hashKey string, field string, increment int64, timeout ...time.Duration) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.HIncrBy(ctx, hashKey, field, increment).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// HIncrByFloat 字段增加浮点值
+func (c *RedisClient) HIncrByFloat(hashKey string, field string, increment float64, timeout ...time.Duration) (float64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.HIncrByFloat(ctx, hashKey, field, increment).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(float64), nil
+}
+
+// HExists 字段是否存在
+func (c *RedisClient) HExists(hashKey string, field string, timeout ...time.Duration) (bool, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.HExists(ctx, hashKey, field).Result()
+	}, timeout...)
+	if err != nil {
+		return false, err
+	}
+	return res.(bool), nil
+}
+
+// HLen 字段数量
+func (c *RedisClient) HLen(hashKey string, timeout ...time.Duration) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.HLen(ctx, hashKey).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// HKeys 所有字段名
+func (c *RedisClient) HKeys(hashKey string, timeout ...time.Duration) ([]string, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.HKeys(ctx, hashKey).Result()
+	}, timeout...)
+	if err != nil {
+		return nil, err
+	}
+	return res.([]string), nil
+}
+
+// HVals 所有字段值
+func (c *RedisClient) HVals(hashKey string, timeout ...time.Duration) ([]string, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.HVals(ctx, hashKey).Result()
+	}, timeout...)
+	if err != nil {
+		return nil, err
+	}
+	return res.([]string), nil
+}
+
+// HScan 按游标扫描一批字段，返回 [field1, value1, field2, value2 ...] 和下一个游标，游标为 0 表示结束
+func (c *RedisClient) HScan(hashKey string, cursor uint64, match string, count int64, timeout ...time.Duration) ([]string, uint64, error) {
+	var next uint64
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		kvs, n, err := c.rdb.HScan(ctx, hashKey, cursor, match, count).Result()
+		next = n
+		return kvs, err
+	}, timeout...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return res.([]string), next, nil
+}
+
 // --------------------------- Set 操作 ---------------------------
 
 // SAdd 添加一个或多个成员到集合
@@ -256,34 +584,39 @@ func (c *RedisClient) SCard(key string) (int64, error) {
 	return res.(int64), nil
 }
 
-// SPop 取出一个元素并移除
+// SPop 取出一个元素并移除，集合为空返回 ErrNotFound
 func (c *RedisClient) SPop(key string) (string, error) {
 	res, err := c.do(func(ctx context.Context) (any, error) {
 		return c.rdb.SPop(ctx, key).Result()
 	})
 	if err != nil {
-		if err == redis.Nil { // 集合为空
-			return "", nil
-		}
 		return "", err
 	}
 	return res.(string), nil
 }
 
 // ---------------- Lua 原子操作 ----------------
+
+// ExecLua 执行 Lua 脚本，脚本按内容缓存且不淘汰
+// script 必须是固定的源码，不要拼接参数动态生成，变化的值通过 keys / args 传入，否则缓存无限增长；
+// 常用脚本优先使用 RegisterScript
 func (c *RedisClient) ExecLua(script string, keys []string, args ...any) (any, error) {
 	return c.do(func(ctx context.Context) (any, error) {
-		lua := redis.NewScript(script)
-		return lua.Run(ctx, c.rdb, keys, args...).Result()
+		return cachedScript(script).Run(ctx, c.rdb, keys, args...).Result()
 	})
 }
 
-// BRPopLPush 封装
+// BRPopLPush 阻塞从 source 右侧弹出并推入 dest 左侧，超时返回 ErrNotFound
+// timeoutSeconds、timeout 约定同 BLPop
 func (c *RedisClient) BRPopLPush(source, dest string, timeoutSeconds int, timeout ...time.Duration) (string, error) {
+	block, total, err := c.blockTimeout(timeoutSeconds, timeout)
+	if err != nil {
+		return "", err
+	}
 	res, err := c.do(func(ctx context.Context) (any, error) {
 		// 这里使用 Redis 原生 BRPopLPush
-		return c.rdb.BRPopLPush(ctx, source, dest, time.Duration(timeoutSeconds)*time.Second).Result()
-	}, timeout...)
+		return c.rdb.BRPopLPush(ctx, source, dest, block).Result()
+	}, total)
 	if err != nil {
 		return "", err
 	}
@@ -332,6 +665,123 @@ func (c *RedisClient) RPop(key string, timeout ...time.Duration) (string, error)
 	return "", nil
 }
 
+// RPush 将一个或多个值推入 list 右侧
+func (c *RedisClient) RPush(key string, values []any, timeout ...time.Duration) (int64, error) {
+	result, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.RPush(ctx, key, values...).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
+
+// LPop 从 list 左侧弹出一个元素，list 为空返回 ErrNotFound
+func (c *RedisClient) LPop(key string, timeout ...time.Duration) (string, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.LPop(ctx, key).Result()
+	}, timeout...)
+	if err != nil {
+		return "", err
+	}
+	return res.(string), nil
+}
+
+// BLPop 阻塞从多个 list 左侧弹出，返回来源 key 和值，超时返回 ErrNotFound
+// timeoutSeconds: 阻塞秒数，必须 > 0（0 表示永久阻塞，与命令超时冲突，不支持）
+// timeout: 阻塞之外的网络超时，命令超时为 timeoutSeconds + timeout，未指定时使用默认超时
+func (c *RedisClient) BLPop(timeoutSeconds int, keys []string, timeout ...time.Duration) (string, string, error) {
+	return c.bpop(true, timeoutSeconds, keys, timeout)
+}
+
+// BRPop 阻塞从多个 list 右侧弹出，约定同 BLPop
+func (c *RedisClient) BRPop(timeoutSeconds int, keys []string, timeout ...time.Duration) (string, string, error) {
+	return c.bpop(false, timeoutSeconds, keys, timeout)
+}
+
+func (c *RedisClient) bpop(left bool, timeoutSeconds int, keys []string, timeout []time.Duration) (string, string, error) {
+	block, total, err := c.blockTimeout(timeoutSeconds, timeout)
+	if err != nil {
+		return "", "", err
+	}
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		if left {
+			return c.rdb.BLPop(ctx, block, keys...).Result()
+		}
+		return c.rdb.BRPop(ctx, block, keys...).Result()
+	}, total)
+	if err != nil {
+		return "", "", err
+	}
+	kv := res.([]string)
+	if len(kv) != 2 {
+		return "", "", ErrNotFound
+	}
+	return kv[0], kv[1], nil
+}
+
+// blockTimeout 阻塞命令的阻塞时长和命令超时（阻塞时长 + 网络超时）
+func (c *RedisClient) blockTimeout(timeoutSeconds int, timeout []time.Duration) (block, total time.Duration, err error) {
+	if timeoutSeconds <= 0 {
+		return 0, 0, fmt.Errorf("cache: blocking timeout must be > 0, got %d", timeoutSeconds)
+	}
+	block = time.Duration(timeoutSeconds) * time.Second
+	extra := c.timeout
+	if len(timeout) > 0 && timeout[0] > 0 {
+		extra = timeout[0]
+	}
+	return block, block + extra, nil
+}
+
+// LRange 获取区间内的元素，0 -1 表示全部
+func (c *RedisClient) LRange(key string, start, stop int64, timeout ...time.Duration) ([]string, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.LRange(ctx, key, start, stop).Result()
+	}, timeout...)
+	if err != nil {
+		return nil, err
+	}
+	return res.([]string), nil
+}
+
+// LLen list 长度
+func (c *RedisClient) LLen(key string, timeout ...time.Duration) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.LLen(ctx, key).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// LTrim 只保留区间内的元素
+func (c *RedisClient) LTrim(key string, start, stop int64, timeout ...time.Duration) error {
+	_, err := c.do(func(ctx context.Context) (any, error) {
+		return nil, c.rdb.LTrim(ctx, key, start, stop).Err()
+	}, timeout...)
+	return err
+}
+
+// LIndex 获取下标处的元素，越界返回 ErrNotFound
+func (c *RedisClient) LIndex(key string, index int64, timeout ...time.Duration) (string, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.LIndex(ctx, key, index).Result()
+	}, timeout...)
+	if err != nil {
+		return "", err
+	}
+	return res.(string), nil
+}
+
+// LSet 设置下标处的元素
+func (c *RedisClient) LSet(key string, index int64, value any, timeout ...time.Duration) error {
+	_, err := c.do(func(ctx context.Context) (any, error) {
+		return nil, c.rdb.LSet(ctx, key, index, value).Err()
+	}, timeout...)
+	return err
+}
+
 // ----------------------------zset--------------------------------
 // 添加或更新成员分数
 func (c *RedisClient) ZAdd(key string, members ...redis.Z) (int64, error) {
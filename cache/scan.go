@@ -0,0 +1,238 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultScanCount = 100
+
+// ---------------- 游标命令 ----------------
+
+// Scan 按游标扫描一批 key，返回 key 和下一个游标，游标为 0 表示结束
+// cluster 模式下只扫描单个节点，遍历全部 key 请使用 ScanEach
+func (c *RedisClient) Scan(cursor uint64, match string, count int64, timeout ...time.Duration) ([]string, uint64, error) {
+	return c.scanCursor(func(ctx context.Context) *redis.ScanCmd {
+		return c.rdb.Scan(ctx, cursor, match, count)
+	}, timeout...)
+}
+
+// SScan 按游标扫描一批集合成员
+func (c *RedisClient) SScan(key string, cursor uint64, match string, count int64, timeout ...time.Duration) ([]string, uint64, error) {
+	return c.scanCursor(func(ctx context.Context) *redis.ScanCmd {
+		return c.rdb.SScan(ctx, key, cursor, match, count)
+	}, timeout...)
+}
+
+// ZScan 按游标扫描一批有序集合成员，返回 [member1, score1, member2, score2 ...]
+func (c *RedisClient) ZScan(key string, cursor uint64, match string, count int64, timeout ...time.Duration) ([]string, uint64, error) {
+	return c.scanCursor(func(ctx context.Context) *redis.ScanCmd {
+		return c.rdb.ZScan(ctx, key, cursor, match, count)
+	}, timeout...)
+}
+
+func (c *RedisClient) scanCursor(fn func(ctx context.Context) *redis.ScanCmd, timeout ...time.Duration) ([]string, uint64, error) {
+	var next uint64
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		vals, n, err := fn(ctx).Result()
+		next = n
+		return vals, err
+	}, timeout...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return res.([]string), next, nil
+}
+
+// ---------------- 迭代器 ----------------
+// 每批 SCAN 单独计算超时，fn 返回 false 提前结束；同一个 key 可能被返回多次，调用方需幂等
+
+// ScanEach 遍历匹配 match 的所有 key，cluster 模式下依次遍历每个主节点
+//
+//	err := cli.ScanEach("player:*", 500, func(key string) bool {
+//		fmt.Println(key)
+//		return true
+//	})
+func (c *RedisClient) ScanEach(match string, count int64, fn func(key string) bool) error {
+	if count <= 0 {
+		count = defaultScanCount
+	}
+	nodes, err := c.scanNodes()
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		var cursor uint64
+		for {
+			res, err := c.do(func(ctx context.Context) (any, error) {
+				keys, n, err := node.Scan(ctx, cursor, match, count).Result()
+				cursor = n
+				return keys, err
+			})
+			if err != nil {
+				return err
+			}
+			for _, key := range res.([]string) {
+				if !fn(key) {
+					return nil
+				}
+			}
+			if cursor == 0 {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// HScanEach 遍历 hash 的字段
+func (c *RedisClient) HScanEach(key, match string, count int64, fn func(field, value string) bool) error {
+	return c.eachPair(func(cursor uint64) ([]string, uint64, error) {
+		return c.HScan(key, cursor, match, count)
+	}, fn)
+}
+
+// SScanEach 遍历集合成员
+func (c *RedisClient) SScanEach(key, match string, count int64, fn func(member string) bool) error {
+	var cursor uint64
+	for {
+		members, next, err := c.SScan(key, cursor, match, count)
+		if err != nil {
+			return err
+		}
+		for _, m := range members {
+			if !fn(m) {
+				return nil
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// ZScanEach 遍历有序集合成员和分数
+func (c *RedisClient) ZScanEach(key, match string, count int64, fn func(member string, score float64) bool) error {
+	return c.eachPair(func(cursor uint64) ([]string, uint64, error) {
+		return c.ZScan(key, cursor, match, count)
+	}, func(member, score string) bool {
+		s, _ := strconv.ParseFloat(score, 64)
+		return fn(member, s)
+	})
+}
+
+// eachPair 遍历返回 [k1, v1, k2, v2 ...] 的游标命令
+func (c *RedisClient) eachPair(scan func(cursor uint64) ([]string, uint64, error), fn func(k, v string) bool) error {
+	var cursor uint64
+	for {
+		kvs, next, err := scan(cursor)
+		if err != nil {
+			return err
+		}
+		for i := 0; i+1 < len(kvs); i += 2 {
+			if !fn(kvs[i], kvs[i+1]) {
+				return nil
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// scanNodes 需要遍历的节点：cluster 为所有主节点，其余为客户端自身
+func (c *RedisClient) scanNodes() ([]redis.Cmdable, error) {
+	cluster, ok := c.rdb.(*redis.ClusterClient)
+	if !ok {
+		return []redis.Cmdable{c.rdb}, nil
+	}
+	var mu sync.Mutex
+	var nodes []redis.Cmdable
+	_, err := c.do(func(ctx context.Context) (any, error) {
+		return nil, cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			mu.Lock()
+			nodes = append(nodes, node)
+			mu.Unlock()
+			return nil
+		})
+	})
+	return nodes, err
+}
+
+// ---------------- 批量删除 ----------------
+
+// Unlink 异步删除 key，返回删除个数
+// cluster 模式下按 slot 拆分执行
+func (c *RedisClient) Unlink(keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	if _, ok := c.rdb.(*redis.ClusterClient); !ok {
+		res, err := c.do(func(ctx context.Context) (any, error) {
+			return c.rdb.Unlink(ctx, keys...).Result()
+		})
+		if err != nil {
+			return 0, err
+		}
+		return res.(int64), nil
+	}
+	cmds, err := c.Pipelined(func(ctx context.Context, pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Unlink(ctx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, cmd := range cmds {
+		n += cmd.(*redis.IntCmd).Val()
+	}
+	return n, nil
+}
+
+// DeleteByPattern 使用 SCAN + UNLINK 分批删除匹配 match 的 key，返回删除个数
+// batch: 每批删除个数，<=0 时默认 100
+// pause: 每批之间的间隔，用于限速，避免对线上实例造成压力
+func (c *RedisClient) DeleteByPattern(match string, batch int64, pause time.Duration) (int64, error) {
+	if batch <= 0 {
+		batch = defaultScanCount
+	}
+	var total int64
+	var delErr error
+	keys := make([]string, 0, batch)
+	flush := func() bool {
+		n, err := c.Unlink(keys...)
+		total += n
+		keys = keys[:0]
+		if err != nil {
+			delErr = err
+			return false
+		}
+		if pause > 0 {
+			time.Sleep(pause)
+		}
+		return true
+	}
+	err := c.ScanEach(match, batch, func(key string) bool {
+		keys = append(keys, key)
+		if int64(len(keys)) >= batch {
+			return flush()
+		}
+		return true
+	})
+	if err == nil && delErr == nil && len(keys) > 0 {
+		flush()
+	}
+	if err != nil {
+		return total, err
+	}
+	return total, delErr
+}
@@ -0,0 +1,293 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tandy9527/js-util/logger"
+)
+
+// Task 到期任务
+type Task struct {
+	ID      string
+	Payload string
+	Due     time.Time // 计划执行时间
+	Attempt int       // 第几次投递，从 1 开始
+}
+
+// TaskHandler 任务处理函数，返回 error 视为失败，按退避重新调度
+type TaskHandler func(ctx context.Context, task Task) error
+
+const (
+	defaultClaimTimeout   = time.Minute
+	defaultSchedulerRetry = 5
+	schedulerPoll         = 500 * time.Millisecond
+	schedulerRetryDelay   = 5 * time.Second
+	maxSchedulerBackoff   = 10 * time.Minute
+)
+
+// KEYS[1]=due KEYS[2]=claimed KEYS[3]=data KEYS[4]=attempts KEYS[5]=dead
+// ARGV[1]=now(ms) ARGV[2]=limit ARGV[3]=claimTimeout(ms) ARGV[4]=maxRetry
+// 先将认领超时的任务放回待执行，再取出到期任务并认领
+// 返回 [id, payload, due, attempt, ...]
+var schedulerPopScript = RegisterScript("cache.scheduler_pop", `
+local now = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', now, 'LIMIT', 0, limit)
+for _, id in ipairs(expired) do
+	redis.call('ZREM', KEYS[2], id)
+	redis.call('ZADD', KEYS[1], now, id)
+end
+local res = {}
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', now, 'WITHSCORES', 'LIMIT', 0, limit)
+for i = 1, #due, 2 do
+	local id = due[i]
+	redis.call('ZREM', KEYS[1], id)
+	local payload = redis.call('HGET', KEYS[3], id)
+	if payload then
+		local n = redis.call('HINCRBY', KEYS[4], id, 1)
+		if n > tonumber(ARGV[4]) then
+			redis.call('HSET', KEYS[5], id, payload)
+			redis.call('HDEL', KEYS[3], id)
+			redis.call('HDEL', KEYS[4], id)
+		else
+			redis.call('ZADD', KEYS[2], now + tonumber(ARGV[3]), id)
+			table.insert(res, id)
+			table.insert(res, payload)
+			table.insert(res, due[i + 1])
+			table.insert(res, tostring(n))
+		end
+	end
+end
+return res
+`)
+
+// KEYS[1]=claimed KEYS[2]=data KEYS[3]=attempts  ARGV[1]=id
+// 仍处于认领状态才删除，认领期间被重新调度的任务不受影响
+var schedulerAckScript = RegisterScript("cache.scheduler_ack", `
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HDEL', KEYS[2], ARGV[1])
+redis.call('HDEL', KEYS[3], ARGV[1])
+return 1
+`)
+
+// KEYS[1]=due KEYS[2]=claimed KEYS[3]=data  ARGV[1]=id ARGV[2]=due(ms) ARGV[3]=只处理认领中的任务
+var schedulerRescheduleScript = RegisterScript("cache.scheduler_reschedule", `
+if redis.call('HEXISTS', KEYS[3], ARGV[1]) == 0 then
+	return 0
+end
+local claimed = redis.call('ZREM', KEYS[2], ARGV[1])
+if ARGV[3] == '1' and claimed == 0 then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+return 1
+`)
+
+// Scheduler 基于 zset 的延时任务调度
+// 任务按计划时间存入 zset，到期后由 Lua 原子取出并认领，多实例竞争消费互不重复；
+// 处理成功 Ack 删除，失败按退避重新调度，认领超时未确认的任务会被重新投递（至少一次），
+// 投递超过 maxRetry 次进入死信 hash
+// cluster 模式下 name 需使用 {hash tag}，保证相关 key 在同一 slot
+type Scheduler struct {
+	cli          *RedisClient
+	name         string
+	due          string // zset：id -> 计划时间 ms
+	claimed      string // zset：id -> 认领截止时间 ms
+	data         string // hash：id -> payload
+	attempts     string // hash：id -> 投递次数
+	dead         string // hash：id -> payload
+	claimTimeout time.Duration
+	maxRetry     int
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler 创建延时任务调度
+// claimTimeout: 任务取出后多久未确认视为处理失败，需大于处理耗时，<=0 时默认 1min
+// maxRetry: 最大投递次数，<=0 时默认 5
+func (c *RedisClient) NewScheduler(name string, claimTimeout time.Duration, maxRetry int) *Scheduler {
+	if claimTimeout <= 0 {
+		claimTimeout = defaultClaimTimeout
+	}
+	if maxRetry <= 0 {
+		maxRetry = defaultSchedulerRetry
+	}
+	return &Scheduler{
+		cli:          c,
+		name:         name,
+		due:          name + ":due",
+		claimed:      name + ":claimed",
+		data:         name + ":data",
+		attempts:     name + ":attempts",
+		dead:         name + ":dead",
+		claimTimeout: claimTimeout,
+		maxRetry:     maxRetry,
+	}
+}
+
+// DeadLetterKey 死信 hash key
+func (s *Scheduler) DeadLetterKey() string {
+	return s.dead
+}
+
+// Schedule 在 at 时间执行任务，id 已存在时覆盖 payload 和执行时间
+func (s *Scheduler) Schedule(id, payload string, at time.Time) error {
+	_, err := s.cli.TxPipelined(func(ctx context.Context, pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, s.data, id, payload)
+		pipe.HDel(ctx, s.attempts, id)
+		pipe.ZRem(ctx, s.claimed, id)
+		pipe.ZAdd(ctx, s.due, redis.Z{Score: float64(at.UnixMilli()), Member: id})
+		return nil
+	})
+	return err
+}
+
+// ScheduleAfter 在 delay 之后执行任务
+func (s *Scheduler) ScheduleAfter(id, payload string, delay time.Duration) error {
+	return s.Schedule(id, payload, time.Now().Add(delay))
+}
+
+// Reschedule 修改任务的执行时间，任务不存在返回 false
+func (s *Scheduler) Reschedule(id string, at time.Time) (bool, error) {
+	return LuaBool(schedulerRescheduleScript.Run(s.cli, []string{s.due, s.claimed, s.data}, id, at.UnixMilli(), 0))
+}
+
+// Cancel 取消任务，任务不存在返回 false
+// 已被取出正在处理的任务无法中断，但之后不会再被投递
+func (s *Scheduler) Cancel(id string) (bool, error) {
+	cmds, err := s.cli.TxPipelined(func(ctx context.Context, pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, s.data, id)
+		pipe.HDel(ctx, s.attempts, id)
+		pipe.ZRem(ctx, s.due, id)
+		pipe.ZRem(ctx, s.claimed, id)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return cmds[0].(*redis.IntCmd).Val() > 0, nil
+}
+
+// Due 任务的计划执行时间，任务不存在或正在处理返回 ErrNotFound
+func (s *Scheduler) Due(id string) (time.Time, error) {
+	score, err := s.cli.ZScore(s.due, id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(int64(score)), nil
+}
+
+// Len 待执行（含未到期）任务数
+func (s *Scheduler) Len() (int64, error) {
+	return s.cli.ZCard(s.due)
+}
+
+// Pop 取出并认领最多 limit 个到期任务，同时将认领超时的任务放回待执行
+func (s *Scheduler) Pop(limit int) ([]Task, error) {
+	if limit <= 0 {
+		limit = 1
+	}
+	res, err := LuaStrings(schedulerPopScript.Run(s.cli,
+		[]string{s.due, s.claimed, s.data, s.attempts, s.dead},
+		time.Now().UnixMilli(), limit, s.claimTimeout.Milliseconds(), s.maxRetry))
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]Task, 0, len(res)/4)
+	for i := 0; i+3 < len(res); i += 4 {
+		due, _ := strconv.ParseFloat(res[i+2], 64)
+		attempt, _ := strconv.Atoi(res[i+3])
+		tasks = append(tasks, Task{
+			ID:      res[i],
+			Payload: res[i+1],
+			Due:     time.UnixMilli(int64(due)),
+			Attempt: attempt,
+		})
+	}
+	return tasks, nil
+}
+
+// Ack 确认处理成功并删除任务
+// 处理期间任务被重新调度或认领已超时时不删除，返回 false
+func (s *Scheduler) Ack(id string) (bool, error) {
+	return LuaBool(schedulerAckScript.Run(s.cli, []string{s.claimed, s.data, s.attempts}, id))
+}
+
+// Nack 处理失败，delay 后重新投递
+func (s *Scheduler) Nack(id string, delay time.Duration) (bool, error) {
+	return LuaBool(schedulerRescheduleScript.Run(s.cli, []string{s.due, s.claimed, s.data},
+		id, time.Now().Add(delay).UnixMilli(), 1))
+}
+
+// Start 启动 workers 个消费协程，无到期任务时每 500ms 轮询一次
+func (s *Scheduler) Start(handler TaskHandler, workers int) {
+	if workers <= 0 {
+		workers = 1
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for i := 0; i < workers; i++ {
+		s.wg.Add(1)
+		go s.work(ctx, handler)
+	}
+	logger.Infof("[Scheduler] %s started, workers=%d", s.name, workers)
+}
+
+// Stop 停止消费，等待正在处理的任务完成
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	s.wg.Wait()
+	logger.Infof("[Scheduler] %s stopped", s.name)
+}
+
+func (s *Scheduler) work(ctx context.Context, handler TaskHandler) {
+	defer s.wg.Done()
+	for ctx.Err() == nil {
+		tasks, err := s.Pop(1)
+		if err != nil {
+			logger.Errorf("[Scheduler] %s pop error: %v", s.name, err)
+		}
+		if len(tasks) == 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(schedulerPoll):
+			}
+			continue
+		}
+
+		task := tasks[0]
+		if err := handler(ctx, task); err != nil {
+			// 按投递次数指数退避
+			delay := min(schedulerRetryDelay<<min(task.Attempt-1, 10), maxSchedulerBackoff)
+			logger.Warnf("[Scheduler] %s task %s attempt %d failed, retry in %v: %v", s.name, task.ID, task.Attempt, delay, err)
+			if _, err := s.Nack(task.ID, delay); err != nil {
+				logger.Errorf("[Scheduler] %s nack error: %v", s.name, err)
+			}
+			continue
+		}
+		if _, err := s.Ack(task.ID); err != nil {
+			logger.Errorf("[Scheduler] %s ack error: %v", s.name, err)
+		}
+	}
+}
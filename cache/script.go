@@ -0,0 +1,251 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Script 具名 Lua 脚本，SHA1 只在注册时计算一次
+// 执行时使用 EVALSHA，服务端没有缓存（NOSCRIPT）时自动回退为 EVAL
+type Script struct {
+	name   string
+	script *redis.Script
+}
+
+var (
+	scriptsMu sync.RWMutex
+	scripts   = make(map[string]*Script)
+
+	// ExecLua 按脚本内容缓存，避免每次重新计算 SHA1，不会淘汰
+	luaCache sync.Map // string -> *redis.Script
+)
+
+// RegisterScript 注册具名脚本，一般在包级变量初始化时调用
+// 名称重复时 panic，属于编码错误
+//
+//	var incrCapped = cache.RegisterScript("incr_capped", `...`)
+//	n, err := cache.LuaInt64(incrCapped.Run(cli, []string{key}, max))
+func RegisterScript(name, src string) *Script {
+	s, err := registerScript(name, src)
+	if err != nil {
+		panic(err.Error())
+	}
+	return s
+}
+
+func registerScript(name, src string) (*Script, error) {
+	scriptsMu.Lock()
+	defer scriptsMu.Unlock()
+	if _, ok := scripts[name]; ok {
+		return nil, fmt.Errorf("cache: script %s registered twice", name)
+	}
+	s := &Script{name: name, script: redis.NewScript(src)}
+	scripts[name] = s
+	return s, nil
+}
+
+// RegisterScriptFS 注册 fsys 中匹配 pattern 的 .lua 文件，脚本名为去掉扩展名的文件名
+// 脚本名已注册时返回错误，此前的文件已注册
+//
+//	//go:embed lua/*.lua
+//	var luaFS embed.FS
+//
+//	cache.RegisterScriptFS(luaFS, "lua/*.lua")
+func RegisterScriptFS(fsys fs.FS, pattern string) error {
+	files, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if path.Ext(file) != ".lua" {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		if _, err := registerScript(strings.TrimSuffix(path.Base(file), ".lua"), string(data)); err != nil {
+			return fmt.Errorf("%w (%s)", err, file)
+		}
+	}
+	return nil
+}
+
+// LookupScript 按名称查找脚本，未注册返回 nil
+func LookupScript(name string) *Script {
+	scriptsMu.RLock()
+	defer scriptsMu.RUnlock()
+	return scripts[name]
+}
+
+// ScriptNames 所有已注册脚本名
+func ScriptNames() []string {
+	scriptsMu.RLock()
+	defer scriptsMu.RUnlock()
+	names := make([]string, 0, len(scripts))
+	for name := range scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Name 脚本名
+func (s *Script) Name() string { return s.name }
+
+// Hash 脚本 SHA1
+func (s *Script) Hash() string { return s.script.Hash() }
+
+// Run 在客户端 c 上执行脚本
+func (s *Script) Run(c *RedisClient, keys []string, args ...any) (any, error) {
+	return c.do(func(ctx context.Context) (any, error) {
+		return s.script.Run(ctx, c.rdb, keys, args...).Result()
+	})
+}
+
+// RunScript 按名称执行已注册的脚本
+func (c *RedisClient) RunScript(name string, keys []string, args ...any) (any, error) {
+	s := LookupScript(name)
+	if s == nil {
+		return nil, fmt.Errorf("cache: script %s not registered", name)
+	}
+	return s.Run(c, keys, args...)
+}
+
+// LoadScripts 将所有已注册脚本 SCRIPT LOAD 到服务端，cluster 模式下加载到每个分片
+// NewRedisManager 连接成功后自动调用；Redis 重启或 SCRIPT FLUSH 后执行时会自动回退为 EVAL
+func (c *RedisClient) LoadScripts(timeout ...time.Duration) error {
+	scriptsMu.RLock()
+	list := make([]*Script, 0, len(scripts))
+	for _, s := range scripts {
+		list = append(list, s)
+	}
+	scriptsMu.RUnlock()
+
+	for _, s := range list {
+		_, err := c.do(func(ctx context.Context) (any, error) {
+			return nil, s.script.Load(ctx, c.rdb).Err()
+		}, timeout...)
+		if err != nil {
+			return fmt.Errorf("cache: load script %s: %w", s.name, err)
+		}
+	}
+	return nil
+}
+
+func cachedScript(src string) *redis.Script {
+	if s, ok := luaCache.Load(src); ok {
+		return s.(*redis.Script)
+	}
+	s, _ := luaCache.LoadOrStore(src, redis.NewScript(src))
+	return s.(*redis.Script)
+}
+
+// ---------------- 结果解码 ----------------
+// 用法：n, err := cache.LuaInt64(cli.RunScript("name", keys, args...))
+// Lua 返回值到 Go 的转换：number -> int64（小数被截断），string -> string，
+// table -> []any，true -> 1，false/nil -> ErrNotFound
+
+// LuaInt64 解码整数结果
+func LuaInt64(res any, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	switch v := res.(type) {
+	case int64:
+		return v, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+	return 0, fmt.Errorf("cache: lua result %T is not int", res)
+}
+
+// LuaFloat64 解码浮点结果，脚本中需以字符串返回（tostring）才能保留小数
+func LuaFloat64(res any, err error) (float64, error) {
+	if err != nil {
+		return 0, err
+	}
+	switch v := res.(type) {
+	case int64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("cache: lua result %T is not float", res)
+}
+
+// LuaBool 解码布尔结果，非 0 整数为 true
+func LuaBool(res any, err error) (bool, error) {
+	if IsNotFound(err) {
+		return false, nil
+	}
+	n, err := LuaInt64(res, err)
+	return n != 0, err
+}
+
+// LuaString 解码字符串结果
+func LuaString(res any, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	switch v := res.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	}
+	return "", fmt.Errorf("cache: lua result %T is not string", res)
+}
+
+// LuaStrings 解码字符串数组结果，数组中的 nil 元素转为空字符串
+func LuaStrings(res any, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := res.([]any)
+	if !ok {
+		return nil, fmt.Errorf("cache: lua result %T is not array", res)
+	}
+	out := make([]string, len(arr))
+	for i, v := range arr {
+		switch val := v.(type) {
+		case string:
+			out[i] = val
+		case int64:
+			out[i] = strconv.FormatInt(val, 10)
+		case nil:
+		default:
+			return nil, fmt.Errorf("cache: lua result[%d] %T is not string", i, v)
+		}
+	}
+	return out, nil
+}
+
+// LuaInt64s 解码整数数组结果
+func LuaInt64s(res any, err error) ([]int64, error) {
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := res.([]any)
+	if !ok {
+		return nil, fmt.Errorf("cache: lua result %T is not array", res)
+	}
+	out := make([]int64, len(arr))
+	for i, v := range arr {
+		n, err := LuaInt64(v, nil)
+		if err != nil {
+			return nil, fmt.Errorf("cache: lua result[%d]: %w", i, err)
+		}
+		out[i] = n
+	}
+	return out, nil
+}
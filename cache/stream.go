@@ -0,0 +1,348 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tandy9527/js-util/logger"
+)
+
+// ---------------- Stream 命令 ----------------
+
+// XAdd 追加消息，返回消息 id
+// maxLen > 0 时近似裁剪（MAXLEN ~），保留最近 maxLen 条
+func (c *RedisClient) XAdd(stream string, values map[string]any, maxLen int64, timeout ...time.Duration) (string, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.XAdd(ctx, &redis.XAddArgs{
+			Stream: stream,
+			MaxLen: maxLen,
+			Approx: maxLen > 0,
+			Values: values,
+		}).Result()
+	}, timeout...)
+	if err != nil {
+		return "", err
+	}
+	return res.(string), nil
+}
+
+// XLen 消息条数
+func (c *RedisClient) XLen(stream string, timeout ...time.Duration) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.XLen(ctx, stream).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// XTrimMaxLen 近似裁剪，保留最近 maxLen 条
+func (c *RedisClient) XTrimMaxLen(stream string, maxLen int64, timeout ...time.Duration) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.XTrimMaxLenApprox(ctx, stream, maxLen, 0).Result()
+	}, timeout...)
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// XGroupCreate 创建消费组，stream 不存在时自动创建，组已存在不报错
+// start: "$" 只消费新消息，"0" 从头消费
+func (c *RedisClient) XGroupCreate(stream, group, start string, timeout ...time.Duration) error {
+	_, err := c.do(func(ctx context.Context) (any, error) {
+		err := c.rdb.XGroupCreateMkStream(ctx, stream, group, start).Err()
+		if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return nil, nil
+		}
+		return nil, err
+	}, timeout...)
+	return err
+}
+
+// XReadGroup 以消费组方式读取，id 为 ">" 读取新消息，"0" 读取本消费者未确认的消息
+// block < 0 不阻塞，> 0 最多阻塞 block，超时无消息返回 ErrNotFound
+// block == 0（BLOCK 0 永久阻塞）与命令超时冲突，不支持，返回错误
+func (c *RedisClient) XReadGroup(stream, group, consumer, id string, count int64, block time.Duration) ([]redis.XMessage, error) {
+	if block == 0 {
+		return nil, fmt.Errorf("cache: XReadGroup block must be non-zero")
+	}
+	timeout := c.timeout
+	if block > 0 {
+		timeout += block
+	}
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: consumer,
+			Streams:  []string{stream, id},
+			Count:    count,
+			Block:    block,
+		}).Result()
+	}, timeout)
+	if err != nil {
+		return nil, err
+	}
+	streams := res.([]redis.XStream)
+	if len(streams) == 0 {
+		return nil, nil
+	}
+	return streams[0].Messages, nil
+}
+
+// XAck 确认消息
+func (c *RedisClient) XAck(stream, group string, ids ...string) (int64, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.XAck(ctx, stream, group, ids...).Result()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// XClaim 将空闲超过 minIdle 的指定消息转移给 consumer
+func (c *RedisClient) XClaim(stream, group, consumer string, minIdle time.Duration, ids ...string) ([]redis.XMessage, error) {
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		return c.rdb.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   stream,
+			Group:    group,
+			Consumer: consumer,
+			MinIdle:  minIdle,
+			Messages: ids,
+		}).Result()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.([]redis.XMessage), nil
+}
+
+// XAutoClaim 从 start 开始扫描并转移空闲超过 minIdle 的消息，返回消息和下一次扫描的起点
+// 起点返回 "0-0" 表示已扫描完整个待确认列表
+func (c *RedisClient) XAutoClaim(stream, group, consumer string, minIdle time.Duration, start string, count int64) ([]redis.XMessage, string, error) {
+	var next string
+	res, err := c.do(func(ctx context.Context) (any, error) {
+		msgs, n, err := c.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   stream,
+			Group:    group,
+			Consumer: consumer,
+			MinIdle:  minIdle,
+			Start:    start,
+			Count:    count,
+		}).Result()
+		next = n
+		return msgs, err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return res.([]redis.XMessage), next, nil
+}
+
+// ---------------- 消费组 Worker ----------------
+
+// StreamHandler 消息处理函数，与 kafka.MessageHandler 风格一致
+type StreamHandler func(ctx context.Context, id string, values map[string]any) error
+
+// StreamConfig 消费组配置
+type StreamConfig struct {
+	Stream     string
+	Group      string
+	Consumer   string        // 消费者名，同组内唯一
+	Count      int64         // 单次读取条数，默认 10
+	Block      time.Duration // 阻塞等待时间，默认 2s
+	MinIdle    time.Duration // 待确认消息空闲超过该时长会被接管，默认 1min，需大于一批消息的最长处理耗时（含重试）
+	RetryCount int           // 处理失败重试次数
+	RetryDelay time.Duration // 重试间隔
+	DeadStream string        // 重试用尽后转入的死信 stream，为空则直接确认丢弃
+	MaxLen     int64         // >0 时定期近似裁剪 stream
+}
+
+// StreamConsumer 消费组 Worker
+// 启动时先处理本消费者遗留的待确认消息，随后消费新消息，
+// 并定期通过 XAUTOCLAIM 接管其他消费者（已崩溃）长期未确认的消息；
+// 接管的消息交给消费协程串行处理，handler 不会被并发调用
+// 处理耗时超过 MinIdle 的消息可能被其他消费者接管而重复处理（至少一次）
+type StreamConsumer struct {
+	cli     *RedisClient
+	cfg     StreamConfig
+	handler StreamHandler
+	claimed chan []redis.XMessage
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewStreamConsumer 创建消费组 Worker，消费组不存在时自动创建
+func (c *RedisClient) NewStreamConsumer(cfg StreamConfig, handler StreamHandler) (*StreamConsumer, error) {
+	if cfg.Count <= 0 {
+		cfg.Count = 10
+	}
+	if cfg.Block <= 0 {
+		cfg.Block = 2 * time.Second
+	}
+	if cfg.MinIdle <= 0 {
+		cfg.MinIdle = time.Minute
+	}
+	if worst := time.Duration(cfg.RetryCount+1) * cfg.RetryDelay; cfg.MinIdle <= worst {
+		logger.Warnf("[Stream] %s MinIdle %v <= retry time %v, messages may be claimed while handling", cfg.Stream, cfg.MinIdle, worst)
+	}
+	if err := c.XGroupCreate(cfg.Stream, cfg.Group, "0"); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &StreamConsumer{
+		cli:     c,
+		cfg:     cfg,
+		handler: handler,
+		claimed: make(chan []redis.XMessage),
+		ctx:     ctx,
+		cancel:  cancel,
+	}, nil
+}
+
+// Start 启动消费
+func (s *StreamConsumer) Start() {
+	s.wg.Add(2)
+	go s.consume()
+	go s.maintain()
+	logger.Infof("[Stream] %s/%s consumer %s started", s.cfg.Stream, s.cfg.Group, s.cfg.Consumer)
+}
+
+// Stop 停止消费，等待正在处理的消息完成
+func (s *StreamConsumer) Stop() {
+	s.cancel()
+	s.wg.Wait()
+	logger.Infof("[Stream] %s/%s consumer %s stopped", s.cfg.Stream, s.cfg.Group, s.cfg.Consumer)
+}
+
+func (s *StreamConsumer) consume() {
+	defer s.wg.Done()
+	// 先处理一遍自身遗留的待确认消息，游标逐批前移，处理失败仍未确认的消息留待接管
+	id := "0"
+	pending := true
+	for s.ctx.Err() == nil {
+		select {
+		case msgs := <-s.claimed:
+			s.handle(msgs)
+			continue
+		default:
+		}
+		block := s.cfg.Block
+		if pending {
+			block = -1
+		}
+		msgs, err := s.cli.XReadGroup(s.cfg.Stream, s.cfg.Group, s.cfg.Consumer, id, s.cfg.Count, block)
+		if err != nil {
+			if !IsNotFound(err) {
+				logger.Errorf("[Stream] %s read error: %v", s.cfg.Stream, err)
+				s.sleep(time.Second)
+			}
+			continue
+		}
+		if pending {
+			if len(msgs) == 0 {
+				pending = false
+				id = ">"
+				continue
+			}
+			id = msgs[len(msgs)-1].ID
+		}
+		s.handle(msgs)
+	}
+}
+
+// maintain 定期接管空闲消息并裁剪 stream，接管的消息交给 consume 处理
+func (s *StreamConsumer) maintain() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.cfg.MinIdle / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			start := "0-0"
+			for s.ctx.Err() == nil {
+				msgs, next, err := s.cli.XAutoClaim(s.cfg.Stream, s.cfg.Group, s.cfg.Consumer, s.cfg.MinIdle, start, s.cfg.Count)
+				if err != nil {
+					logger.Errorf("[Stream] %s autoclaim error: %v", s.cfg.Stream, err)
+					break
+				}
+				if len(msgs) > 0 {
+					logger.Warnf("[Stream] %s claimed %d idle messages", s.cfg.Stream, len(msgs))
+					select {
+					case s.claimed <- msgs:
+					case <-s.ctx.Done():
+						return
+					}
+				}
+				if next == "0-0" || next == "" {
+					break
+				}
+				start = next
+			}
+			if s.cfg.MaxLen > 0 {
+				if _, err := s.cli.XTrimMaxLen(s.cfg.Stream, s.cfg.MaxLen); err != nil {
+					logger.Errorf("[Stream] %s trim error: %v", s.cfg.Stream, err)
+				}
+			}
+		}
+	}
+}
+
+func (s *StreamConsumer) handle(msgs []redis.XMessage) {
+	for _, m := range msgs {
+		success := false
+		for attempt := 0; attempt <= s.cfg.RetryCount; attempt++ {
+			if err := s.handler(s.ctx, m.ID, m.Values); err != nil {
+				logger.Errorf("[Stream] handler error, id=%s attempt %d: %v", m.ID, attempt+1, err)
+				if attempt < s.cfg.RetryCount && !s.sleep(s.cfg.RetryDelay) {
+					return
+				}
+			} else {
+				success = true
+				break
+			}
+		}
+
+		if !success && s.cfg.DeadStream != "" {
+			if _, err := s.cli.XAdd(s.cfg.DeadStream, m.Values, 0); err != nil {
+				// 死信写入失败不确认，等待下次接管
+				logger.Errorf("[Stream] send to dead stream failed, id=%s: %v", m.ID, err)
+				continue
+			}
+			logger.Warnf("[Stream] message sent to dead stream: id=%s", m.ID)
+		}
+
+		if _, err := s.cli.XAck(s.cfg.Stream, s.cfg.Group, m.ID); err != nil {
+			logger.Errorf("[Stream] ack error, id=%s: %v", m.ID, err)
+		}
+	}
+}
+
+// sleep 等待 d，期间 Stop 返回 false
+func (s *StreamConsumer) sleep(d time.Duration) bool {
+	return sleepCtx(s.ctx, d)
+}
+
+// sleepCtx 等待 d，ctx 取消时提前返回 false
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/tandy9527/js-util/cache"
+)
+
+// KEYS[1]=key  ARGV[1]=cost  ARGV[2]=limit  ARGV[3]=window(ms)
+var fixedWindowScript = cache.RegisterScript("ratelimit.fixed_window", `
+local cost = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local n = redis.call('INCRBY', KEYS[1], cost)
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+	ttl = tonumber(ARGV[3])
+end
+if n > limit then
+	-- 被拒绝的请求不计数
+	redis.call('DECRBY', KEYS[1], cost)
+	return {0, limit - n + cost, ttl}
+end
+return {1, limit - n, 0}
+`)
+
+// FixedWindow 固定窗口计数，窗口从第一次请求开始计时
+type FixedWindow struct {
+	cli    *cache.RedisClient
+	prefix string
+	limit  int64
+	window time.Duration
+}
+
+// NewFixedWindow 每个 window 内最多 limit 次
+// limit 必须 > 0，window 不小于 1ms，否则 panic，属于配置错误
+func NewFixedWindow(cli *cache.RedisClient, prefix string, limit int64, window time.Duration) *FixedWindow {
+	checkWindow("fixed window", prefix, limit, window)
+	return &FixedWindow{cli: cli, prefix: prefix, limit: limit, window: window}
+}
+
+func (f *FixedWindow) Allow(key string) (*Result, error) {
+	return f.AllowN(key, 1)
+}
+
+func (f *FixedWindow) AllowN(key string, n int64) (*Result, error) {
+	return run(f.cli, fixedWindowScript, f.limit, f.prefix+":"+key, n, f.limit, f.window.Milliseconds())
+}
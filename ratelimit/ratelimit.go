@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tandy9527/js-util/cache"
+	"github.com/tandy9527/js-util/logger"
+	"github.com/tandy9527/js-util/tools/str_tools"
+)
+
+// Result 限流结果
+type Result struct {
+	Allowed    bool          // 是否放行
+	Limit      int64         // 配额上限
+	Remaining  int64         // 剩余配额
+	RetryAfter time.Duration // 被拒绝时建议的重试等待时间
+}
+
+// Limiter 限流器
+type Limiter interface {
+	// Allow 消耗 1 个配额
+	Allow(key string) (*Result, error)
+	// AllowN 消耗 n 个配额
+	AllowN(key string, n int64) (*Result, error)
+}
+
+// 统一使用 Redis 服务端时间，避免多实例时钟不一致
+const nowLua = `
+redis.replicate_commands()
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+`
+
+// checkWindow 校验窗口类限流参数：window 按毫秒传给 PEXPIRE，不足 1ms 时为 0 会直接删除计数
+func checkWindow(kind, prefix string, limit int64, window time.Duration) {
+	if limit <= 0 || window < time.Millisecond {
+		panic(fmt.Sprintf("ratelimit: %s %s requires limit > 0 and window >= 1ms, got limit=%d window=%v", kind, prefix, limit, window))
+	}
+}
+
+// run 执行脚本并解析 {allowed, remaining, retryAfterMs}
+func run(cli *cache.RedisClient, script *cache.Script, limit int64, key string, args ...any) (*Result, error) {
+	res, err := script.Run(cli, []string{key}, args...)
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := res.([]any)
+	if !ok || len(arr) != 3 {
+		return nil, fmt.Errorf("ratelimit: unexpected script result %v", res)
+	}
+	allowed, _ := arr[0].(int64)
+	remaining, _ := arr[1].(int64)
+	retry, _ := arr[2].(int64)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &Result{
+		Allowed:    allowed == 1,
+		Limit:      limit,
+		Remaining:  remaining,
+		RetryAfter: time.Duration(retry) * time.Millisecond,
+	}, nil
+}
+
+// IPKey 以客户端真实 IP 作为限流 key
+func IPKey(r *http.Request) string {
+	return str_tools.GetRealIP(r)
+}
+
+// Middleware HTTP 限流中间件，超限返回 429 并设置 Retry-After
+// keyFunc 为空时按 IP 限流，Redis 异常时放行
+func Middleware(l Limiter, keyFunc func(r *http.Request) string, next http.Handler) http.Handler {
+	if keyFunc == nil {
+		keyFunc = IPKey
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := keyFunc(r)
+		res, err := l.Allow(key)
+		if err != nil {
+			logger.Warnf("[RateLimit] allow %s failed, pass through: %v", key, err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", strconv.FormatInt(res.Limit, 10))
+		w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(res.Remaining, 10))
+		if !res.Allowed {
+			secs := int64((res.RetryAfter + time.Second - 1) / time.Second)
+			w.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
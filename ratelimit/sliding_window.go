@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/tandy9527/js-util/cache"
+	"github.com/tandy9527/js-util/tools/str_tools"
+)
+
+// 滑动日志：zset 中每个请求一个成员，score 为请求时间
+// KEYS[1]=key  ARGV[1]=window(ms)  ARGV[2]=limit  ARGV[3]=cost  ARGV[4]=成员前缀
+var slidingWindowScript = cache.RegisterScript("ratelimit.sliding_window", nowLua+`
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+if count + cost > limit then
+	local retry = window
+	local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+	if oldest[2] then
+		retry = tonumber(oldest[2]) + window - now
+	end
+	return {0, limit - count, retry}
+end
+for i = 1, cost do
+	redis.call('ZADD', KEYS[1], now, ARGV[4] .. ':' .. i)
+end
+redis.call('PEXPIRE', KEYS[1], window)
+return {1, limit - count - cost, 0}
+`)
+
+// SlidingWindow 滑动日志，任意 window 时间段内最多 limit 次，精确但每个请求占用一个 zset 成员
+type SlidingWindow struct {
+	cli    *cache.RedisClient
+	prefix string
+	limit  int64
+	window time.Duration
+}
+
+// NewSlidingWindow 任意 window 内最多 limit 次
+// limit 必须 > 0，window 不小于 1ms，否则 panic，属于配置错误
+func NewSlidingWindow(cli *cache.RedisClient, prefix string, limit int64, window time.Duration) *SlidingWindow {
+	checkWindow("sliding window", prefix, limit, window)
+	return &SlidingWindow{cli: cli, prefix: prefix, limit: limit, window: window}
+}
+
+func (s *SlidingWindow) Allow(key string) (*Result, error) {
+	return s.AllowN(key, 1)
+}
+
+func (s *SlidingWindow) AllowN(key string, n int64) (*Result, error) {
+	member := str_tools.StrSplingInt(str_tools.RandLetterStr(8)+":", time.Now().UnixNano())
+	return run(s.cli, slidingWindowScript, s.limit, s.prefix+":"+key, s.window.Milliseconds(), s.limit, n, member)
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"fmt"
+
+	"github.com/tandy9527/js-util/cache"
+)
+
+// KEYS[1]=key  ARGV[1]=rate(个/秒)  ARGV[2]=burst  ARGV[3]=cost
+var tokenBucketScript = cache.RegisterScript("ratelimit.token_bucket", nowLua+`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(b[1])
+local ts = tonumber(b[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+local allowed = 0
+local retry = 0
+if tokens >= cost then
+	tokens = tokens - cost
+	allowed = 1
+else
+	retry = math.ceil((cost - tokens) * 1000 / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, math.floor(tokens), retry}
+`)
+
+// TokenBucket 令牌桶，按 rate 匀速补充，最多积累 burst 个，允许突发
+type TokenBucket struct {
+	cli    *cache.RedisClient
+	prefix string
+	rate   float64
+	burst  int64
+}
+
+// NewTokenBucket rate: 每秒补充令牌数，burst: 桶容量
+// rate、burst 必须 > 0，否则 panic，属于配置错误
+func NewTokenBucket(cli *cache.RedisClient, prefix string, rate float64, burst int64) *TokenBucket {
+	if !(rate > 0) || burst <= 0 {
+		panic(fmt.Sprintf("ratelimit: token bucket %s requires rate > 0 and burst > 0, got rate=%v burst=%d", prefix, rate, burst))
+	}
+	return &TokenBucket{cli: cli, prefix: prefix, rate: rate, burst: burst}
+}
+
+func (t *TokenBucket) Allow(key string) (*Result, error) {
+	return t.AllowN(key, 1)
+}
+
+func (t *TokenBucket) AllowN(key string, n int64) (*Result, error) {
+	return run(t.cli, tokenBucketScript, t.burst, t.prefix+":"+key, t.rate, t.burst, n)
+}
@@ -0,0 +1,478 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tandy9527/js-util/cache"
+	"github.com/tandy9527/js-util/tools/jwt_tools"
+)
+
+var (
+	// ErrNotFound 会话不存在或已过期
+	ErrNotFound = cache.ErrNotFound
+	// ErrKicked 会话被踢下线（其他设备登录或主动踢出）
+	ErrKicked = errors.New("session: kicked")
+	// ErrUIDMismatch token 中的 uid 与会话不一致
+	ErrUIDMismatch = errors.New("session: uid mismatch")
+	// ErrConflict 同一 uid 并发登录过于频繁，创建会话重试仍冲突
+	ErrConflict = errors.New("session: concurrent create conflict")
+)
+
+// createRetries 创建会话遇到并发登录时的最大尝试次数
+const createRetries = 5
+
+// Policy 同一 uid 的登录策略
+type Policy int
+
+const (
+	// PolicyMulti 允许多端同时在线，MaxDevices > 0 时超出后踢掉最久未活跃的会话
+	PolicyMulti Policy = iota
+	// PolicySingle 单点登录，新登录踢掉该 uid 的所有旧会话
+	PolicySingle
+	// PolicySingleDevice 每种设备只保留一个会话，如手机和 PC 可同时在线
+	PolicySingleDevice
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PolicySingle:
+		return "single"
+	case PolicySingleDevice:
+		return "device"
+	default:
+		return "multi"
+	}
+}
+
+// 会话 hash 字段，自定义数据以 "d." 为前缀
+const (
+	fieldUID     = "uid"
+	fieldDevice  = "device"
+	fieldIP      = "ip"
+	fieldCreated = "created"
+	fieldSeen    = "seen"
+	dataPrefix   = "d."
+)
+
+// key 均带 {uid} hash tag，cluster 下同一玩家的 key 落在同一 slot
+// 索引 zset：prefix:{uid}:idx  member=sid score=最后活跃时间(ms)
+// 会话 hash：prefix:{uid}:s:<sid>
+// 踢出标记：prefix:{uid}:k:<sid>
+
+// KEYS[1]=索引  KEYS[2]=新会话  KEYS[2i+1]/KEYS[2i+2]=第 i 个已有会话的 hash / 踢出标记
+// ARGV[1]=sid ARGV[2]=now(ms) ARGV[3]=ttl(ms) ARGV[4]=policy ARGV[5]=maxDevices
+// ARGV[6]=device ARGV[7]=ip ARGV[8]=踢出标记 ttl(ms) ARGV[9]=已有会话数 n ARGV[10]=uid
+// ARGV[11...10+n]=已有会话 sid，与 KEYS 一一对应
+// ARGV[11+n...]=自定义数据 field value 对，与会话字段一起写入
+// 返回被踢掉的 sid 列表；索引中出现未传入 KEYS 的会话（并发登录）时返回 nil，由调用方重试
+var createScript = cache.RegisterScript("session.create", `
+local idx = KEYS[1]
+local now = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+local n = tonumber(ARGV[9])
+local known = {}
+for i = 1, n do
+	known[ARGV[10 + i]] = i
+end
+redis.call('zremrangebyscore', idx, '-inf', now - ttl)
+local sids = redis.call('zrange', idx, 0, -1)
+for _, sid in ipairs(sids) do
+	if not known[sid] then
+		return false
+	end
+end
+local kicked = {}
+local function kick(sid)
+	local i = known[sid]
+	redis.call('del', KEYS[2 * i + 1])
+	redis.call('zrem', idx, sid)
+	redis.call('set', KEYS[2 * i + 2], '1', 'px', ARGV[8])
+	table.insert(kicked, sid)
+end
+for _, sid in ipairs(sids) do
+	if ARGV[4] == 'single' then
+		kick(sid)
+	elseif ARGV[4] == 'device' and redis.call('hget', KEYS[2 * known[sid] + 1], 'device') == ARGV[6] then
+		kick(sid)
+	end
+end
+local max = tonumber(ARGV[5])
+if max > 0 then
+	local cnt = redis.call('zcard', idx)
+	if cnt >= max then
+		for _, sid in ipairs(redis.call('zrange', idx, 0, cnt - max)) do
+			kick(sid)
+		end
+	end
+end
+local fields = {'uid', ARGV[10], 'device', ARGV[6], 'ip', ARGV[7], 'created', now, 'seen', now}
+for i = 11 + n, #ARGV do
+	table.insert(fields, ARGV[i])
+end
+redis.call('hset', KEYS[2], unpack(fields))
+redis.call('pexpire', KEYS[2], ttl)
+redis.call('zadd', idx, now, ARGV[1])
+redis.call('pexpire', idx, ttl)
+return kicked
+`)
+
+// KEYS[1]=索引  KEYS[2]=会话
+// ARGV[1]=sid ARGV[2]=now(ms) ARGV[3]=ttl(ms) ARGV[4]=最长存活(ms)，0 不限制
+// 返回 1 续期成功，0 会话不存在或已超过最长存活
+var touchScript = cache.RegisterScript("session.touch", `
+if redis.call('exists', KEYS[2]) == 0 then
+	redis.call('zrem', KEYS[1], ARGV[1])
+	return 0
+end
+local now = tonumber(ARGV[2])
+local life = tonumber(ARGV[4])
+if life > 0 then
+	local created = tonumber(redis.call('hget', KEYS[2], 'created'))
+	if created and now - created >= life then
+		redis.call('del', KEYS[2])
+		redis.call('zrem', KEYS[1], ARGV[1])
+		return 0
+	end
+end
+redis.call('hset', KEYS[2], 'seen', now)
+redis.call('pexpire', KEYS[2], ARGV[3])
+redis.call('zadd', KEYS[1], now, ARGV[1])
+redis.call('pexpire', KEYS[1], ARGV[3])
+return 1
+`)
+
+// KEYS[1]=索引  KEYS[2]=会话  KEYS[3]=踢出标记
+// ARGV[1]=sid ARGV[2]=踢出标记 ttl(ms)，0 不写标记
+var destroyScript = cache.RegisterScript("session.destroy", `
+local n = redis.call('del', KEYS[2])
+redis.call('zrem', KEYS[1], ARGV[1])
+if n > 0 and ARGV[2] ~= '0' then
+	redis.call('set', KEYS[3], '1', 'px', ARGV[2])
+end
+return n
+`)
+
+// Config 会话配置
+type Config struct {
+	Prefix      string        // key 前缀，默认 "session"
+	TTL         time.Duration // 空闲过期时间，每次 Touch 顺延，默认 30min
+	MaxLifetime time.Duration // 最长存活时间，超过后 Touch 失败需重新登录，0 不限制
+	Policy      Policy        // 登录策略
+	MaxDevices  int           // PolicyMulti 下同时在线的最大会话数，0 不限制
+	KickedTTL   time.Duration // 踢出标记保留时长，期间 Load 返回 ErrKicked，默认 10min
+}
+
+// Session 会话
+type Session struct {
+	ID        string
+	UID       int64
+	Device    string
+	IP        string
+	CreatedAt time.Time
+	LastSeen  time.Time
+	Data      map[string]string // 自定义数据
+}
+
+// Store 基于 Redis 的玩家会话存储
+type Store struct {
+	cli *cache.RedisClient
+	cfg Config
+}
+
+// NewStore 创建会话存储
+func NewStore(cli *cache.RedisClient, cfg Config) *Store {
+	if cfg.Prefix == "" {
+		cfg.Prefix = "session"
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 30 * time.Minute
+	}
+	if cfg.KickedTTL <= 0 {
+		cfg.KickedTTL = 10 * time.Minute
+	}
+	return &Store{cli: cli, cfg: cfg}
+}
+
+// sid 格式为 <uid>.<随机串>，便于从 sid 还原 uid 定位 key
+// Load/Touch/Destroy 只凭 sid 操作，随机部分使用 crypto/rand 生成 24 字节，不可猜测
+func newID(uid int64) (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(uid, 10) + "." + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func parseID(sid string) (int64, bool) {
+	i := strings.IndexByte(sid, '.')
+	if i <= 0 {
+		return 0, false
+	}
+	uid, err := strconv.ParseInt(sid[:i], 10, 64)
+	return uid, err == nil
+}
+
+func (s *Store) base(uid int64) string {
+	return fmt.Sprintf("%s:{%d}:", s.cfg.Prefix, uid)
+}
+
+func (s *Store) indexKey(uid int64) string { return s.base(uid) + "idx" }
+
+func (s *Store) sessionKey(uid int64, sid string) string { return s.base(uid) + "s:" + sid }
+
+func (s *Store) kickedKey(uid int64, sid string) string { return s.base(uid) + "k:" + sid }
+
+// Create 登录创建会话，按策略踢掉旧会话，返回新会话和被踢掉的 sid
+func (s *Store) Create(uid int64, device, ip string, data map[string]string) (*Session, []string, error) {
+	sid, err := newID(uid)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	kicked, err := s.create(uid, sid, device, ip, data, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &Session{
+		ID:        sid,
+		UID:       uid,
+		Device:    device,
+		IP:        ip,
+		CreatedAt: time.UnixMilli(now.UnixMilli()),
+		LastSeen:  time.UnixMilli(now.UnixMilli()),
+		Data:      data,
+	}, kicked, nil
+}
+
+// create 先读出已有会话，把它们的 key 传入 KEYS 后执行 createScript，
+// 期间有并发登录加入新会话时重试
+func (s *Store) create(uid int64, sid, device, ip string, data map[string]string, now time.Time) ([]string, error) {
+	for i := 0; i < createRetries; i++ {
+		zs, err := s.cli.ZRevRangeWithScores(s.indexKey(uid), 0, -1)
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, 2+2*len(zs))
+		keys = append(keys, s.indexKey(uid), s.sessionKey(uid, sid))
+		args := make([]any, 0, 10+len(zs)+2*len(data))
+		args = append(args, sid, now.UnixMilli(), s.cfg.TTL.Milliseconds(), s.cfg.Policy.String(), s.cfg.MaxDevices,
+			device, ip, s.cfg.KickedTTL.Milliseconds(), len(zs), uid)
+		for _, z := range zs {
+			old := z.Member.(string)
+			keys = append(keys, s.sessionKey(uid, old), s.kickedKey(uid, old))
+			args = append(args, old)
+		}
+		for k, v := range data {
+			args = append(args, dataPrefix+k, v)
+		}
+		kicked, err := cache.LuaStrings(createScript.Run(s.cli, keys, args...))
+		if cache.IsNotFound(err) {
+			continue
+		}
+		return kicked, err
+	}
+	return nil, ErrConflict
+}
+
+// Load 读取会话，不顺延过期时间
+// 不存在返回 ErrNotFound，被踢出返回 ErrKicked
+func (s *Store) Load(sid string) (*Session, error) {
+	uid, ok := parseID(sid)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	m, err := s.cli.HGetAll(s.sessionKey(uid, sid))
+	if err != nil {
+		return nil, err
+	}
+	if len(m) == 0 {
+		kicked, err := s.cli.Exists(s.kickedKey(uid, sid))
+		if err != nil {
+			return nil, err
+		}
+		if kicked {
+			return nil, ErrKicked
+		}
+		return nil, ErrNotFound
+	}
+	return decode(sid, m), nil
+}
+
+// Touch 顺延会话过期时间，会话不存在或超过 MaxLifetime 返回 ErrNotFound
+func (s *Store) Touch(sid string) error {
+	uid, ok := parseID(sid)
+	if !ok {
+		return ErrNotFound
+	}
+	ok, err := cache.LuaBool(touchScript.Run(s.cli,
+		[]string{s.indexKey(uid), s.sessionKey(uid, sid)},
+		sid, time.Now().UnixMilli(), s.cfg.TTL.Milliseconds(), s.cfg.MaxLifetime.Milliseconds()))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Verify 校验 token 并加载、顺延会话，token 中的 uid 必须与会话一致
+//
+//	sess, err := store.Verify(token, secret, ip, sid)
+func (s *Store) Verify(token, secret, ip, sid string) (*Session, error) {
+	uid, err := jwt_tools.ParseToken(token, secret, ip)
+	if err != nil {
+		return nil, err
+	}
+	if sidUID, ok := parseID(sid); !ok || sidUID != uid {
+		return nil, ErrUIDMismatch
+	}
+	if err := s.Touch(sid); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			// 区分过期和被踢
+			_, err = s.Load(sid)
+			if err == nil {
+				err = ErrNotFound
+			}
+		}
+		return nil, err
+	}
+	return s.Load(sid)
+}
+
+// SetData 写入自定义数据
+func (s *Store) SetData(sid string, data map[string]string) error {
+	uid, ok := parseID(sid)
+	if !ok {
+		return ErrNotFound
+	}
+	fields := make(map[string]any, len(data))
+	for k, v := range data {
+		fields[dataPrefix+k] = v
+	}
+	key := s.sessionKey(uid, sid)
+	// 只更新存在的会话，避免给已过期会话写出无 TTL 的 key
+	return s.cli.Watch(func(ctx context.Context, tx *redis.Tx) error {
+		n, err := tx.Exists(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrNotFound
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, key, fields)
+			return nil
+		})
+		return err
+	}, 0, []string{key})
+}
+
+// Destroy 注销会话
+func (s *Store) Destroy(sid string) error {
+	return s.destroy(sid, false)
+}
+
+// KickSession 踢出单个会话，之后 Load 返回 ErrKicked
+func (s *Store) KickSession(sid string) error {
+	return s.destroy(sid, true)
+}
+
+func (s *Store) destroy(sid string, kick bool) error {
+	uid, ok := parseID(sid)
+	if !ok {
+		return nil
+	}
+	var kickedTTL int64
+	if kick {
+		kickedTTL = s.cfg.KickedTTL.Milliseconds()
+	}
+	_, err := destroyScript.Run(s.cli,
+		[]string{s.indexKey(uid), s.sessionKey(uid, sid), s.kickedKey(uid, sid)},
+		sid, kickedTTL)
+	return err
+}
+
+// List 列出 uid 的所有在线会话，按最后活跃时间倒序
+func (s *Store) List(uid int64) ([]*Session, error) {
+	zs, err := s.cli.ZRevRangeWithScores(s.indexKey(uid), 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	if len(zs) == 0 {
+		return nil, nil
+	}
+	cmds, err := s.cli.Pipelined(func(ctx context.Context, pipe redis.Pipeliner) error {
+		for _, z := range zs {
+			pipe.HGetAll(ctx, s.sessionKey(uid, z.Member.(string)))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*Session, 0, len(zs))
+	for i, cmd := range cmds {
+		m := cmd.(*redis.MapStringStringCmd).Val()
+		if len(m) == 0 {
+			// 已过期，索引稍后在 Create/Touch 时清理
+			continue
+		}
+		list = append(list, decode(zs[i].Member.(string), m))
+	}
+	return list, nil
+}
+
+// Kick 踢掉 uid 的所有会话，except 中的 sid 保留，返回被踢掉的 sid
+func (s *Store) Kick(uid int64, except ...string) ([]string, error) {
+	zs, err := s.cli.ZRevRangeWithScores(s.indexKey(uid), 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	keep := make(map[string]bool, len(except))
+	for _, sid := range except {
+		keep[sid] = true
+	}
+	var kicked []string
+	for _, z := range zs {
+		sid := z.Member.(string)
+		if keep[sid] {
+			continue
+		}
+		if err := s.KickSession(sid); err != nil {
+			return kicked, err
+		}
+		kicked = append(kicked, sid)
+	}
+	return kicked, nil
+}
+
+func decode(sid string, m map[string]string) *Session {
+	sess := &Session{
+		ID:     sid,
+		Device: m[fieldDevice],
+		IP:     m[fieldIP],
+		Data:   make(map[string]string),
+	}
+	sess.UID, _ = strconv.ParseInt(m[fieldUID], 10, 64)
+	if ms, err := strconv.ParseInt(m[fieldCreated], 10, 64); err == nil {
+		sess.CreatedAt = time.UnixMilli(ms)
+	}
+	if ms, err := strconv.ParseInt(m[fieldSeen], 10, 64); err == nil {
+		sess.LastSeen = time.UnixMilli(ms)
+	}
+	for k, v := range m {
+		if strings.HasPrefix(k, dataPrefix) {
+			sess.Data[strings.TrimPrefix(k, dataPrefix)] = v
+		}
+	}
+	return sess
+}